@@ -17,7 +17,10 @@ limitations under the License.
 package v1beta1
 
 import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
@@ -28,24 +31,629 @@ type HotelReservationAppSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
 
-	LogicNodeIp   string `json:"logicNodeIp"`
-	LogicNodeName string `json:"logicNodeName"`
+	// NetworkMode selects how the services reach each other. InCluster (the default) renders
+	// their configuration with ClusterIP Service DNS names and exposes no host ports. NodeIP is
+	// the legacy mode addressing the logic and data nodes by IP through host ports and NodePorts
+	//+kubebuilder:default=InCluster
+	//+optional
+	NetworkMode NetworkMode `json:"networkMode,omitempty"`
 
-	DataNodeName string `json:"dataNodeName"`
-	DataNodeIp   string `json:"dataNodeIp"`
+	// LogicNodeIp is the IP of the node running the logic tier, only used in NodeIP mode
+	//+optional
+	LogicNodeIp string `json:"logicNodeIp,omitempty"`
+	// LogicNodeName is a shorthand pinning the logic tier to a single node, it is ignored
+	// when Placement.Logic is set
+	//+optional
+	LogicNodeName string `json:"logicNodeName,omitempty"`
+
+	// DataNodeName is a shorthand pinning the data tier (and the search service) to a single
+	// node, it is ignored when Placement.Data is set
+	//+optional
+	DataNodeName string `json:"dataNodeName,omitempty"`
+	// DataNodeIp is the IP of the node running the data tier, only used in NodeIP mode
+	//+optional
+	DataNodeIp string `json:"dataNodeIp,omitempty"`
 	// The mirror image corresponding to the business service, including the dockerregistryprefix
+	// When set every image without a registry host is pulled from this prefix, which replaces the
+	// registry host only, e.g. "mirror.local/hotel/" turns the default "jaegertracing/all-in-one"
+	// image into "mirror.local/hotel/jaegertracing/all-in-one"
 	DockerRegistryPrefix string `json:"dockerRegistryPrefix"`
+
+	// ImagePullSecrets are added to every pod the operator creates so that images can be
+	// pulled from a private registry or mirror
+	//+optional
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+
+	// Placement controls where the pods of the logic tier (logic services, consul and jaeger)
+	// and of the data tier (memcached and mongodb) are scheduled
+	//+optional
+	Placement *PlacementSpec `json:"placement,omitempty"`
+
+	// Exposure controls the type of the Services of each tier and how the frontend is
+	// exposed outside of the cluster
+	//+optional
+	Exposure *ExposureSpec `json:"exposure,omitempty"`
+
+	// Components overrides the settings of individual components, keyed by component name
+	// (memcached-rate, mongodb-geo, consul, jaeger, frontend, search...). Components that are
+	// not listed run with the operator defaults
+	//+optional
+	Components map[string]ComponentSpec `json:"components,omitempty"`
+
+	// Storage is the storage of every mongodb instance, the Storage of a mongodb component
+	// overrides it field by field
+	//+optional
+	Storage *StorageSpec `json:"storage,omitempty"`
+
+	// MongoDB configures how the mongodb instances are run
+	//+optional
+	MongoDB *MongoDBSpec `json:"mongodb,omitempty"`
+
+	// Memcached configures how the memcached instances are run
+	//+optional
+	Memcached *MemcachedSpec `json:"memcached,omitempty"`
+
+	// DisruptionBudget limits the voluntary disruptions, such as node drains, of every component
+	// that runs more than one replica. Defaults to one unavailable replica at a time
+	//+optional
+	DisruptionBudget *DisruptionBudgetSpec `json:"disruptionBudget,omitempty"`
+
+	// Backup schedules mongodump backups of every mongodb instance
+	//+optional
+	Backup *BackupSpec `json:"backup,omitempty"`
+
+	// Restore seeds the mongodb instances of a new app from a backup before the logic services
+	// start. It can only be set when the app is created
+	//+optional
+	Restore *RestoreSpec `json:"restore,omitempty"`
+
+	// DeletionPolicy controls what happens to the PersistentVolumeClaims of the mongodb
+	// instances when the HotelReservationApp is deleted. Retain (the default) keeps them,
+	// Delete removes them and Snapshot takes a VolumeSnapshot of each of them before removing them
+	//+kubebuilder:default=Retain
+	//+optional
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// VolumeSnapshotClassName is the class of the VolumeSnapshots taken by the Snapshot
+	// deletion policy, the cluster default class is used when empty
+	//+optional
+	VolumeSnapshotClassName *string `json:"volumeSnapshotClassName,omitempty"`
+}
+
+// DeletionPolicy is what happens to the data of the application when it is deleted
+// +kubebuilder:validation:Enum=Delete;Retain;Snapshot
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyDelete removes the PersistentVolumeClaims of the mongodb instances
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+	// DeletionPolicyRetain leaves the PersistentVolumeClaims of the mongodb instances in place
+	DeletionPolicyRetain DeletionPolicy = "Retain"
+	// DeletionPolicySnapshot stops the mongodb instances and snapshots their PersistentVolumeClaims,
+	// then removes them once every snapshot is ready to use. It falls back to Retain and reports
+	// the app as Degraded when the VolumeSnapshot kind is not installed
+	DeletionPolicySnapshot DeletionPolicy = "Snapshot"
+)
+
+// NetworkMode is the way the services of the application address each other
+// +kubebuilder:validation:Enum=InCluster;NodeIP
+type NetworkMode string
+
+const (
+	// NetworkModeInCluster addresses services through their ClusterIP Service DNS names
+	NetworkModeInCluster NetworkMode = "InCluster"
+	// NetworkModeNodeIP addresses services through the IPs of the logic and data nodes
+	NetworkModeNodeIP NetworkMode = "NodeIP"
+)
+
+// PlacementSpec holds the scheduling constraints of each tier of the application
+type PlacementSpec struct {
+	// Logic is the placement of the logic services, consul and jaeger
+	//+optional
+	Logic *Placement `json:"logic,omitempty"`
+
+	// Data is the placement of memcached and mongodb
+	//+optional
+	Data *Placement `json:"data,omitempty"`
+}
+
+// Placement holds the scheduling constraints applied to a pod template
+type Placement struct {
+	// NodeSelector restricts the pods to nodes with matching labels
+	//+optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Affinity holds the node affinity, pod affinity and pod anti-affinity of the pods
+	//+optional
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+
+	// Tolerations allow the pods to be scheduled on tainted nodes
+	//+optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// TopologySpreadConstraints spread the pods across topology domains
+	//+optional
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+}
+
+// ExposureSpec holds the exposure of the Services of each tier
+type ExposureSpec struct {
+	// Data is the exposure of the memcached and mongodb Services. It defaults to ClusterIP,
+	// or to NodePort on the legacy ports 31001-31003 and 30001-30006 in NodeIP mode
+	//+optional
+	Data *ServiceExposure `json:"data,omitempty"`
+
+	// Logic is the exposure of the Services of the logic services, consul and jaeger, it
+	// defaults to ClusterIP
+	//+optional
+	Logic *ServiceExposure `json:"logic,omitempty"`
+
+	// Frontend is the exposure of the frontend, it defaults to a ClusterIP Service
+	//+optional
+	Frontend *FrontendExposure `json:"frontend,omitempty"`
+}
+
+// LegacyNodePorts are the node ports of the memcached and mongodb Services in NodeIP mode when
+// Exposure.Data does not pin them
+var LegacyNodePorts = map[string]int32{
+	"memcached-rate":         31001,
+	"memcached-profile":      31002,
+	"memcached-reservation":  31003,
+	"mongodb-geo":            30001,
+	"mongodb-profile":        30002,
+	"mongodb-rate":           30003,
+	"mongodb-recommendation": 30004,
+	"mongodb-reservation":    30005,
+	"mongodb-user":           30006,
+}
+
+// ServiceExposure describes the Services created for a tier
+type ServiceExposure struct {
+	// Type of the Services
+	//+kubebuilder:validation:Enum=ClusterIP;NodePort;LoadBalancer
+	//+kubebuilder:default=ClusterIP
+	//+optional
+	Type corev1.ServiceType `json:"type,omitempty"`
+
+	// NodePorts pins the node port of NodePort and LoadBalancer Services by Service name,
+	// e.g. mongodb-geo: 30001. For Services with several ports (consul, jaeger) it applies to
+	// the first one. Ports that are not pinned get a node port allocated by Kubernetes
+	//+optional
+	NodePorts map[string]int32 `json:"nodePorts,omitempty"`
+
+	// Annotations are added to the Services, e.g. to configure a cloud load balancer
+	//+optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// FrontendExposure describes the Service and the optional Ingress of the frontend
+type FrontendExposure struct {
+	ServiceExposure `json:",inline"`
+
+	// Ingress routes external HTTP traffic to the frontend Service when set
+	//+optional
+	Ingress *IngressSpec `json:"ingress,omitempty"`
+}
+
+// IngressSpec describes the Ingress of the frontend
+type IngressSpec struct {
+	// Host the Ingress serves the frontend on, all hosts when empty
+	//+optional
+	Host string `json:"host,omitempty"`
+
+	// Path the frontend is served under, defaults to /
+	//+kubebuilder:default=/
+	//+optional
+	Path string `json:"path,omitempty"`
+
+	// IngressClassName selects the ingress controller, the cluster default is used when empty
+	//+optional
+	IngressClassName *string `json:"ingressClassName,omitempty"`
+
+	// TLSSecretName is the name of the Secret holding the TLS certificate of Host, the
+	// Ingress serves plain HTTP when empty
+	//+optional
+	TLSSecretName string `json:"tlsSecretName,omitempty"`
+
+	// Annotations are added to the Ingress
+	//+optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// ComponentSpec overrides the defaults the operator uses for a single component
+type ComponentSpec struct {
+	// Enabled can be set to false to leave a logic service out of the application, the
+	// memcached and mongodb instances backing the service are left out with it
+	//+optional
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// Replicas is the number of pods to run for the component, defaults to 1
+	//+kubebuilder:validation:Minimum=0
+	//+optional
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Resources are the compute resource requests and limits of the component's main container
+	//+optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Image replaces the default image repository of the component, e.g. "mongo"
+	//+optional
+	Image string `json:"image,omitempty"`
+
+	// Tag replaces the default image tag of the component
+	//+optional
+	Tag string `json:"tag,omitempty"`
+
+	// Digest pins the image of the component, e.g. "sha256:3f1b...", it takes precedence over Tag
+	//+kubebuilder:validation:Pattern=`^[a-z0-9]+:[a-f0-9]{32,}$`
+	//+optional
+	Digest string `json:"digest,omitempty"`
+
+	// Env is merged into the environment of the component's main container, entries with the
+	// same name as a default variable replace it
+	//+optional
+	Env []corev1.EnvVar `json:"env,omitempty"`
+
+	// ImagePullPolicy of the component's containers, defaults to IfNotPresent
+	//+kubebuilder:validation:Enum=Always;Never;IfNotPresent
+	//+optional
+	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+
+	// Placement overrides the placement of the component's tier, its node selector is merged
+	// into the tier's one while its other fields replace those of the tier
+	//+optional
+	Placement *Placement `json:"placement,omitempty"`
+
+	// Storage overrides the storage of a mongodb instance, it is ignored by other components
+	//+optional
+	Storage *StorageSpec `json:"storage,omitempty"`
+
+	// Autoscaling scales a logic service with a HorizontalPodAutoscaler, which then owns its
+	// replica count in place of Replicas. It requires the InCluster network mode
+	//+optional
+	Autoscaling *AutoscalingSpec `json:"autoscaling,omitempty"`
+
+	// DisruptionBudget replaces the disruption budget of the spec for the component
+	//+optional
+	DisruptionBudget *DisruptionBudgetSpec `json:"disruptionBudget,omitempty"`
+
+	// Probes replace the default probes of the component's main container
+	//+optional
+	Probes *ProbesSpec `json:"probes,omitempty"`
+}
+
+// ProbesSpec overrides the probes of a container, each probe that is set replaces the default one
+type ProbesSpec struct {
+	// Liveness restarts the container when it fails
+	//+optional
+	Liveness *corev1.Probe `json:"liveness,omitempty"`
+
+	// Readiness removes the pod from the endpoints of its Services while it fails
+	//+optional
+	Readiness *corev1.Probe `json:"readiness,omitempty"`
+
+	// Startup holds off the other probes until it succeeds
+	//+optional
+	Startup *corev1.Probe `json:"startup,omitempty"`
+}
+
+// DisruptionBudgetSpec describes the PodDisruptionBudget of a component, at most one of its
+// fields may be set
+type DisruptionBudgetSpec struct {
+	// MinAvailable is the number or percentage of replicas that must remain available
+	//+optional
+	MinAvailable *intstr.IntOrString `json:"minAvailable,omitempty"`
+
+	// MaxUnavailable is the number or percentage of replicas that may be unavailable
+	//+optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+// AutoscalingSpec describes the HorizontalPodAutoscaler of a logic service
+type AutoscalingSpec struct {
+	// MinReplicas is the lower limit of the number of replicas, defaults to 1
+	//+kubebuilder:validation:Minimum=1
+	//+optional
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+
+	// MaxReplicas is the upper limit of the number of replicas
+	//+kubebuilder:validation:Minimum=1
+	MaxReplicas int32 `json:"maxReplicas"`
+
+	// TargetCPUUtilizationPercentage is the average CPU utilization of the pods, relative to
+	// their requests, the autoscaler aims for. Defaults to 80
+	//+kubebuilder:validation:Minimum=1
+	//+optional
+	TargetCPUUtilizationPercentage *int32 `json:"targetCPUUtilizationPercentage,omitempty"`
+
+	// CustomMetric is a metric of the pods the autoscaler also scales on, served by a custom
+	// metrics API adapter
+	//+optional
+	CustomMetric *CustomMetricSpec `json:"customMetric,omitempty"`
+}
+
+// CustomMetricSpec is a per-pod metric the autoscaler aims to keep at an average value
+type CustomMetricSpec struct {
+	// Name of the metric, e.g. "grpc_requests_per_second"
+	Name string `json:"name"`
+
+	// TargetAverageValue is the average value of the metric across the pods the autoscaler aims for
+	TargetAverageValue resource.Quantity `json:"targetAverageValue"`
+}
+
+// MemcachedSpec configures the memcached instances. The settings left unset keep the defaults of
+// memcached
+type MemcachedSpec struct {
+	// MemoryMB is the memory of each instance for items in megabytes (-m)
+	//+kubebuilder:validation:Minimum=1
+	//+optional
+	MemoryMB *int32 `json:"memoryMB,omitempty"`
+
+	// Threads is the number of threads of each instance (-t)
+	//+kubebuilder:validation:Minimum=1
+	//+optional
+	Threads *int32 `json:"threads,omitempty"`
+
+	// MaxConnections is the maximum number of simultaneous connections of each instance (-c)
+	//+kubebuilder:validation:Minimum=1
+	//+optional
+	MaxConnections *int32 `json:"maxConnections,omitempty"`
+
+	// MaxItemSize is the maximum size of an item (-I), such as "1m" or "512k"
+	//+kubebuilder:validation:Pattern=`^[0-9]+[kKmM]?$`
+	//+optional
+	MaxItemSize string `json:"maxItemSize,omitempty"`
+
+	// Replicas of each instance, defaults to 1. Each replica holds a share of the keys: with more
+	// than one, an instance runs as a StatefulSet behind a headless Service and the services are
+	// given the address of every replica. The replicas of a component override this value
+	//+kubebuilder:validation:Minimum=1
+	//+optional
+	Replicas *int32 `json:"replicas,omitempty"`
+}
+
+// MongoDBSpec configures the mongodb instances
+type MongoDBSpec struct {
+	// ReplicaSet runs each mongodb instance as a replica set instead of a standalone server.
+	// The operator generates the keyfile the members authenticate each other with and initiates
+	// each replica set with a Job. The members are added when the replica set is initiated,
+	// changing their number afterwards requires reconfiguring the replica set by hand
+	//+optional
+	ReplicaSet *MongoDBReplicaSetSpec `json:"replicaSet,omitempty"`
+
+	// Auth enables authentication on the mongodb instances. The operator generates the
+	// credentials of a root user and of the services' user of each instance into the
+	// mongodb-<service>-credentials Secrets, creates the users with a Job and renders the
+	// services' credentials into their config, which then moves to a Secret
+	//+optional
+	Auth *MongoDBAuthSpec `json:"auth,omitempty"`
+}
+
+// MongoDBAuthSpec describes the authentication of the mongodb instances
+type MongoDBAuthSpec struct {
+	// Rotation is increased to generate new passwords for the users of every mongodb instance.
+	// The services switch to a new user, the previous one is only dropped once every logic
+	// service has rolled out with the new credentials
+	//+kubebuilder:validation:Minimum=0
+	//+optional
+	Rotation int64 `json:"rotation,omitempty"`
+}
+
+// MongoDBReplicaSetSpec describes the replica sets of the mongodb instances
+type MongoDBReplicaSetSpec struct {
+	// Members is the number of members of each replica set, defaults to 3. The replicas of a
+	// mongodb component override it
+	//+kubebuilder:validation:Minimum=1
+	//+kubebuilder:default=3
+	//+optional
+	Members int32 `json:"members,omitempty"`
+}
+
+// BackupSpec describes the scheduled backups of the mongodb instances. A CronJob named
+// mongodb-<service>-backup dumps each instance into an archive named after the time of the backup
+type BackupSpec struct {
+	// Schedule of the backups in cron format, e.g. "0 2 * * *"
+	//+kubebuilder:validation:MinLength=1
+	Schedule string `json:"schedule"`
+
+	// Retention is the number of backups kept for each mongodb instance, the older ones are
+	// removed after each backup. Defaults to 7
+	//+kubebuilder:validation:Minimum=1
+	//+kubebuilder:default=7
+	//+optional
+	Retention int32 `json:"retention,omitempty"`
+
+	// Suspend stops scheduling new backups
+	//+optional
+	Suspend bool `json:"suspend,omitempty"`
+
+	// Target is where the backups are stored
+	Target BackupTarget `json:"target"`
+}
+
+// BackupTarget is where backups are stored, exactly one of its fields must be set
+type BackupTarget struct {
+	// PersistentVolumeClaim stores the backups on an existing PersistentVolumeClaim
+	//+optional
+	PersistentVolumeClaim *PVCBackupTarget `json:"persistentVolumeClaim,omitempty"`
+
+	// S3 stores the backups in a bucket of an S3-compatible object store such as MinIO
+	//+optional
+	S3 *S3BackupTarget `json:"s3,omitempty"`
+}
+
+// RestoreSpec names the backup the mongodb instances of a new app are restored from. A Job named
+// mongodb-<service>-restore restores each instance, the logic services are only rolled out once
+// all of them have succeeded
+type RestoreSpec struct {
+	// Backup is the name of the backup to restore, the time its archives are named after such as
+	// "20220101T020000Z". The archive of each mongodb instance is read from the directory or key
+	// prefix of the instance under the source, where scheduled backups store them
+	//+kubebuilder:validation:MinLength=1
+	Backup string `json:"backup"`
+
+	// Source is where the backup is stored
+	Source BackupTarget `json:"source"`
+}
+
+// PVCBackupTarget stores the backups on a PersistentVolumeClaim, in a directory per mongodb instance
+type PVCBackupTarget struct {
+	// ClaimName is the name of the PersistentVolumeClaim in the namespace of the app
+	ClaimName string `json:"claimName"`
+
+	// Path is the directory of the volume the backups are stored under, defaults to its root
+	//+optional
+	Path string `json:"path,omitempty"`
+}
+
+// S3BackupTarget stores the backups in a bucket of an S3-compatible object store, under a prefix
+// per mongodb instance
+type S3BackupTarget struct {
+	// Endpoint is the URL of the object store, e.g. "http://minio.minio.svc:9000". The AWS
+	// endpoint of Region is used when empty
+	//+optional
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Bucket the backups are stored in
+	Bucket string `json:"bucket"`
+
+	// Prefix is the key prefix the backups are stored under
+	//+optional
+	Prefix string `json:"prefix,omitempty"`
+
+	// Region of the bucket, defaults to us-east-1
+	//+optional
+	Region string `json:"region,omitempty"`
+
+	// CredentialsSecretName is the name of a Secret holding the AWS_ACCESS_KEY_ID and
+	// AWS_SECRET_ACCESS_KEY of the object store
+	CredentialsSecretName string `json:"credentialsSecretName"`
+
+	// Image of the S3 client uploading the backups, defaults to amazon/aws-cli
+	//+optional
+	Image string `json:"image,omitempty"`
+}
+
+// StorageSpec describes the volumes of a mongodb instance. The volume claim templates of a
+// StatefulSet cannot change once it is created, so the storage class and access modes only apply
+// to new instances and changes to the emptyDir mode are rejected by the webhook
+type StorageSpec struct {
+	// Size of the PersistentVolumeClaim of each replica, defaults to 1Gi
+	//+optional
+	Size *resource.Quantity `json:"size,omitempty"`
+
+	// StorageClassName of the PersistentVolumeClaims, the cluster default class is used when unset
+	//+optional
+	StorageClassName *string `json:"storageClassName,omitempty"`
+
+	// AccessModes of the PersistentVolumeClaims, defaults to ReadWriteOnce
+	//+optional
+	AccessModes []corev1.PersistentVolumeAccessMode `json:"accessModes,omitempty"`
+
+	// EmptyDir stores the data in an emptyDir volume instead of a PersistentVolumeClaim, the
+	// data is lost with the pod so it is only meant for ephemeral test environments
+	//+optional
+	EmptyDir bool `json:"emptyDir,omitempty"`
+}
+
+// Condition types reported in HotelReservationAppStatus.Conditions
+const (
+	// ConditionAvailable is true when every component has all of its desired replicas ready
+	ConditionAvailable = "Available"
+	// ConditionProgressing is true while one or more components are still rolling out
+	ConditionProgressing = "Progressing"
+	// ConditionDegraded is true when a component failed to reconcile or its workload reports a failure
+	ConditionDegraded = "Degraded"
+	// ConditionReconciled is true when the last reconcile created or updated every resource without error
+	ConditionReconciled = "Reconciled"
+	// ConditionRestored is true once the mongodb instances have been restored from the backup named
+	// in the spec, it is only reported for apps created from a backup
+	ConditionRestored = "Restored"
+)
+
+// Phases of the staged rollout reported in HotelReservationAppStatus.Phase
+const (
+	// PhaseInfrastructure waits for memcached, mongodb, consul and jaeger to become ready
+	PhaseInfrastructure = "Infrastructure"
+	// PhaseBackend rolls out the logic services, each once the services it calls are ready
+	PhaseBackend = "Backend"
+	// PhaseFrontend rolls out the frontend once every backend service is ready
+	PhaseFrontend = "Frontend"
+	// PhaseRunning is reached when every component is ready
+	PhaseRunning = "Running"
+)
+
+// ComponentStatus is the observed state of a single component (memcached-*, mongodb-*, consul,
+// jaeger or one of the logic services)
+type ComponentStatus struct {
+	// Kind of the workload backing the component, Deployment or StatefulSet
+	Kind string `json:"kind"`
+	// DesiredReplicas is the number of replicas requested in the workload spec
+	DesiredReplicas int32 `json:"desiredReplicas"`
+	// ReadyReplicas is the number of replicas reported ready by the workload
+	ReadyReplicas int32 `json:"readyReplicas"`
+	// LastError is the last error met while reconciling the component, if any
+	LastError string `json:"lastError,omitempty"`
+	// Volumes is the state of the PersistentVolumeClaims of a mongodb instance
+	//+optional
+	Volumes []VolumeStatus `json:"volumes,omitempty"`
+	// LastBackupTime is the time of the last successful backup of a mongodb instance
+	//+optional
+	LastBackupTime *metav1.Time `json:"lastBackupTime,omitempty"`
+}
+
+// States of a PersistentVolumeClaim reported in VolumeStatus.State
+const (
+	// VolumeStatePending is reported while the claim is not bound to a volume yet
+	VolumeStatePending = "Pending"
+	// VolumeStateReady is reported when the capacity of the volume matches its request
+	VolumeStateReady = "Ready"
+	// VolumeStateExpanding is reported while the volume is being expanded by its provisioner
+	VolumeStateExpanding = "Expanding"
+	// VolumeStateFileSystemResizePending is reported when the volume has been expanded and its
+	// file system is waiting for a pod to be resized
+	VolumeStateFileSystemResizePending = "FileSystemResizePending"
+)
+
+// VolumeStatus is the observed state of a PersistentVolumeClaim of a mongodb instance
+type VolumeStatus struct {
+	// ClaimName is the name of the PersistentVolumeClaim
+	ClaimName string `json:"claimName"`
+	// Requested is the storage requested by the claim
+	Requested string `json:"requested,omitempty"`
+	// Capacity is the storage of the volume bound to the claim
+	Capacity string `json:"capacity,omitempty"`
+	// State is Pending, Ready, Expanding or FileSystemResizePending
+	State string `json:"state,omitempty"`
 }
 
 // HotelReservationAppStatus defines the observed state of HotelReservationApp
 type HotelReservationAppStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
-	Nodes []string `json:"nodes"`
+
+	// ObservedGeneration is the generation of the spec the status was computed from
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Phase is the current stage of the rollout: Infrastructure, Backend, Frontend or Running
+	Phase string `json:"phase,omitempty"`
+
+	// Conditions are the Available, Progressing, Degraded, Reconciled and Restored conditions of the app
+	//+listType=map
+	//+listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// Components maps the name of each component to its observed state
+	Components map[string]ComponentStatus `json:"components,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+//+kubebuilder:printcolumn:name="Available",type=string,JSONPath=`.status.conditions[?(@.type=="Available")].status`
+//+kubebuilder:printcolumn:name="Progressing",type=string,JSONPath=`.status.conditions[?(@.type=="Progressing")].status`
+//+kubebuilder:printcolumn:name="Degraded",type=string,JSONPath=`.status.conditions[?(@.type=="Degraded")].status`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // HotelReservationApp is the Schema for the hotelreservationapps API
 type HotelReservationApp struct {
@@ -0,0 +1,491 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// log is for logging in this package.
+var hotelreservationapplog = logf.Log.WithName("hotelreservationapp-resource")
+
+// Range of the node ports Kubernetes allocates by default (--service-node-port-range)
+const (
+	minNodePort = 30000
+	maxNodePort = 32767
+)
+
+// validatePath is the path the validating webhook is served on. It is registered by hand rather
+// than through the webhook builder so that the webhook can return warnings
+const validatePath = "/validate-example-njtech-edu-cn-v1beta1-hotelreservationapp"
+
+// SetupWebhookWithManager registers the defaulting and validating webhooks of HotelReservationApp
+// with the webhook server of the manager. Both read the cluster through the API reader so that
+// they see the latest HotelReservationApps and Nodes
+func (r *HotelReservationApp) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	mgr.GetWebhookServer().Register(validatePath, &webhook.Admission{
+		Handler: &hotelReservationAppValidator{reader: mgr.GetAPIReader()},
+	})
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		WithDefaulter(&hotelReservationAppDefaulter{reader: mgr.GetAPIReader()}).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-example-njtech-edu-cn-v1beta1-hotelreservationapp,mutating=true,failurePolicy=fail,sideEffects=None,groups=example.njtech.edu.cn,resources=hotelreservationapps,verbs=create;update,versions=v1beta1,name=mhotelreservationapp.kb.io,admissionReviewVersions=v1
+//+kubebuilder:webhook:path=/validate-example-njtech-edu-cn-v1beta1-hotelreservationapp,mutating=false,failurePolicy=fail,sideEffects=None,groups=example.njtech.edu.cn,resources=hotelreservationapps,verbs=create;update,versions=v1beta1,name=vhotelreservationapp.kb.io,admissionReviewVersions=v1
+//+kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
+
+//+kubebuilder:object:generate=false
+
+// hotelReservationAppDefaulter fills in the fields of a HotelReservationApp left empty
+type hotelReservationAppDefaulter struct {
+	reader client.Reader
+}
+
+var _ admission.CustomDefaulter = &hotelReservationAppDefaulter{}
+
+// Default implements admission.CustomDefaulter. Besides the defaults of the CRD schema it trims
+// the node names and IPs and, in NodeIP mode, fills in a missing node IP with the internal IP of
+// the named node
+func (d *hotelReservationAppDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	app, ok := obj.(*HotelReservationApp)
+	if !ok {
+		return fmt.Errorf("expected a HotelReservationApp but got a %T", obj)
+	}
+	hotelreservationapplog.Info("default", "name", app.Name)
+
+	spec := &app.Spec
+	if spec.NetworkMode == "" {
+		spec.NetworkMode = NetworkModeInCluster
+	}
+	if spec.DeletionPolicy == "" {
+		spec.DeletionPolicy = DeletionPolicyRetain
+	}
+	spec.LogicNodeName = strings.TrimSpace(spec.LogicNodeName)
+	spec.LogicNodeIp = strings.TrimSpace(spec.LogicNodeIp)
+	spec.DataNodeName = strings.TrimSpace(spec.DataNodeName)
+	spec.DataNodeIp = strings.TrimSpace(spec.DataNodeIp)
+	if spec.Exposure != nil && spec.Exposure.Frontend != nil && spec.Exposure.Frontend.Ingress != nil && spec.Exposure.Frontend.Ingress.Path == "" {
+		spec.Exposure.Frontend.Ingress.Path = "/"
+	}
+
+	// The IPs are left empty when the node cannot be read, the validation reports them
+	if spec.NetworkMode == NetworkModeNodeIP {
+		if spec.LogicNodeIp == "" && spec.LogicNodeName != "" {
+			spec.LogicNodeIp = d.internalIP(ctx, spec.LogicNodeName)
+		}
+		if spec.DataNodeIp == "" && spec.DataNodeName != "" {
+			spec.DataNodeIp = d.internalIP(ctx, spec.DataNodeName)
+		}
+	}
+	return nil
+}
+
+// internalIP returns the internal IP of the named node, empty when it cannot be read
+func (d *hotelReservationAppDefaulter) internalIP(ctx context.Context, nodeName string) string {
+	node := &corev1.Node{}
+	if err := d.reader.Get(ctx, types.NamespacedName{Name: nodeName}, node); err != nil {
+		return ""
+	}
+	for _, address := range node.Status.Addresses {
+		if address.Type == corev1.NodeInternalIP {
+			return address.Address
+		}
+	}
+	return ""
+}
+
+//+kubebuilder:object:generate=false
+
+// hotelReservationAppValidator rejects invalid HotelReservationApps and warns about the ones
+// that reference missing nodes
+type hotelReservationAppValidator struct {
+	reader  client.Reader
+	decoder *admission.Decoder
+}
+
+var _ admission.Handler = &hotelReservationAppValidator{}
+
+// InjectDecoder implements admission.DecoderInjector
+func (v *hotelReservationAppValidator) InjectDecoder(decoder *admission.Decoder) error {
+	v.decoder = decoder
+	return nil
+}
+
+// Handle implements admission.Handler. Updates that leave the spec unchanged, such as the
+// ones adding or removing finalizers, are always allowed so that existing apps keep working
+func (v *hotelReservationAppValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	app := &HotelReservationApp{}
+	if err := v.decoder.Decode(req, app); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	hotelreservationapplog.Info("validate", "name", app.Name, "operation", req.Operation)
+
+	var old *HotelReservationApp
+	if req.Operation == admissionv1.Update {
+		old = &HotelReservationApp{}
+		if err := v.decoder.DecodeRaw(req.OldObject, old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		if !app.DeletionTimestamp.IsZero() || equality.Semantic.DeepEqual(old.Spec, app.Spec) {
+			return admission.Allowed("")
+		}
+	}
+
+	allErrs := app.validateSpec()
+	if old != nil {
+		allErrs = append(allErrs, app.validateUpdate(old)...)
+	}
+	collisions, err := v.nodePortCollisions(ctx, app)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	allErrs = append(allErrs, collisions...)
+	warnings := v.missingNodes(ctx, app)
+
+	if len(allErrs) != 0 {
+		return admission.Denied(allErrs.ToAggregate().Error()).WithWarnings(warnings...)
+	}
+	return admission.Allowed("").WithWarnings(warnings...)
+}
+
+// validateSpec checks the fields of the spec that do not depend on the rest of the cluster
+func (r *HotelReservationApp) validateSpec() field.ErrorList {
+	allErrs := field.ErrorList{}
+	specPath := field.NewPath("spec")
+	spec := r.Spec
+
+	nodeIPMode := spec.NetworkMode == NetworkModeNodeIP
+	allErrs = append(allErrs, validateNodeIP(specPath.Child("logicNodeIp"), spec.LogicNodeIp, nodeIPMode)...)
+	allErrs = append(allErrs, validateNodeIP(specPath.Child("dataNodeIp"), spec.DataNodeIp, nodeIPMode)...)
+	allErrs = append(allErrs, validateNodeName(specPath.Child("logicNodeName"), spec.LogicNodeName)...)
+	allErrs = append(allErrs, validateNodeName(specPath.Child("dataNodeName"), spec.DataNodeName)...)
+
+	if spec.Exposure != nil {
+		exposurePath := specPath.Child("exposure")
+		allErrs = append(allErrs, validateExposure(exposurePath.Child("data"), spec.Exposure.Data)...)
+		allErrs = append(allErrs, validateExposure(exposurePath.Child("logic"), spec.Exposure.Logic)...)
+		if spec.Exposure.Frontend != nil {
+			allErrs = append(allErrs, validateExposure(exposurePath.Child("frontend"), &spec.Exposure.Frontend.ServiceExposure)...)
+		}
+	}
+
+	componentNames := make([]string, 0, len(spec.Components))
+	for name := range spec.Components {
+		componentNames = append(componentNames, name)
+	}
+	sort.Strings(componentNames)
+	for _, name := range componentNames {
+		componentPath := specPath.Child("components").Key(name)
+		if autoscaling := spec.Components[name].Autoscaling; autoscaling != nil {
+			allErrs = append(allErrs, validateAutoscaling(componentPath.Child("autoscaling"), name, autoscaling, nodeIPMode)...)
+		}
+		allErrs = append(allErrs, validateDisruptionBudget(componentPath.Child("disruptionBudget"), spec.Components[name].DisruptionBudget)...)
+	}
+	allErrs = append(allErrs, validateDisruptionBudget(specPath.Child("disruptionBudget"), spec.DisruptionBudget)...)
+
+	if spec.Backup != nil {
+		allErrs = append(allErrs, validateBackup(specPath.Child("backup"), spec.Backup)...)
+	}
+	if spec.Restore != nil {
+		allErrs = append(allErrs, validateRestore(specPath.Child("restore"), spec.Restore)...)
+	}
+
+	nodePorts := r.nodePorts()
+	services := make([]string, 0, len(nodePorts))
+	for service := range nodePorts {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+	seen := map[int32]string{}
+	for _, service := range services {
+		port := nodePorts[service]
+		if other, ok := seen[port]; ok {
+			allErrs = append(allErrs, field.Duplicate(specPath.Child("exposure"), fmt.Sprintf("node port %d of %s is also used by %s", port, service, other)))
+		}
+		seen[port] = service
+	}
+	return allErrs
+}
+
+// validateUpdate checks the changes to the spec that are only allowed on creation. The restore
+// of an existing app cannot be added or changed since its databases already hold data, it can
+// only be removed. The emptyDir mode of a mongodb instance cannot change either, it decides the
+// volume claim templates of its StatefulSet which are immutable
+func (r *HotelReservationApp) validateUpdate(old *HotelReservationApp) field.ErrorList {
+	allErrs := field.ErrorList{}
+	specPath := field.NewPath("spec")
+	if r.Spec.Restore != nil && !equality.Semantic.DeepEqual(old.Spec.Restore, r.Spec.Restore) {
+		allErrs = append(allErrs, field.Forbidden(specPath.Child("restore"), "can only be set when the HotelReservationApp is created"))
+	}
+
+	if old.Spec.Storage.emptyDir() != r.Spec.Storage.emptyDir() {
+		return append(allErrs, field.Forbidden(specPath.Child("storage", "emptyDir"), "cannot be changed once the mongodb instances are created"))
+	}
+	instances := map[string]struct{}{}
+	for name := range old.Spec.Components {
+		instances[name] = struct{}{}
+	}
+	for name := range r.Spec.Components {
+		instances[name] = struct{}{}
+	}
+	names := make([]string, 0, len(instances))
+	for name := range instances {
+		if strings.HasPrefix(name, "mongodb-") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if old.emptyDirFor(name) != r.emptyDirFor(name) {
+			allErrs = append(allErrs, field.Forbidden(specPath.Child("components").Key(name).Child("storage", "emptyDir"),
+				"cannot be changed once the mongodb instance is created"))
+		}
+	}
+	return allErrs
+}
+
+// emptyDir returns whether a storage spec asks for emptyDir volumes
+func (s *StorageSpec) emptyDir() bool {
+	return s != nil && s.EmptyDir
+}
+
+// emptyDirFor returns whether the named mongodb instance stores its data in an emptyDir volume,
+// following the storage of the spec and the override of the instance's component
+func (r *HotelReservationApp) emptyDirFor(name string) bool {
+	return r.Spec.Storage.emptyDir() || r.Spec.Components[name].Storage.emptyDir()
+}
+
+// validateNodeIP checks that a node IP is a valid IP address, and that it is set when required
+func validateNodeIP(path *field.Path, ip string, required bool) field.ErrorList {
+	if ip == "" {
+		if required {
+			return field.ErrorList{field.Required(path, "required in NodeIP mode")}
+		}
+		return nil
+	}
+	if net.ParseIP(ip) == nil {
+		return field.ErrorList{field.Invalid(path, ip, "must be a valid IP address")}
+	}
+	return nil
+}
+
+// validateNodeName checks that a node name, when set, is a valid DNS subdomain
+func validateNodeName(path *field.Path, name string) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if name == "" {
+		return allErrs
+	}
+	for _, msg := range validation.IsDNS1123Subdomain(name) {
+		allErrs = append(allErrs, field.Invalid(path, name, msg))
+	}
+	return allErrs
+}
+
+// validateAutoscaling checks that an autoscaled component is a logic service addressed through
+// its Service, and that its replica limits are consistent
+func validateAutoscaling(path *field.Path, component string, autoscaling *AutoscalingSpec, nodeIPMode bool) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if component == "consul" || component == "jaeger" || strings.HasPrefix(component, "memcached-") || strings.HasPrefix(component, "mongodb-") {
+		allErrs = append(allErrs, field.Forbidden(path, "only logic services can be autoscaled"))
+	}
+	if nodeIPMode {
+		allErrs = append(allErrs, field.Forbidden(path, "requires the InCluster network mode, the replicas of a service cannot share its host port"))
+	}
+	if autoscaling.MinReplicas != nil && *autoscaling.MinReplicas > autoscaling.MaxReplicas {
+		allErrs = append(allErrs, field.Invalid(path.Child("maxReplicas"), autoscaling.MaxReplicas, "must not be lower than minReplicas"))
+	}
+	return allErrs
+}
+
+// validateDisruptionBudget checks that a disruption budget sets at most one of its limits
+func validateDisruptionBudget(path *field.Path, budget *DisruptionBudgetSpec) field.ErrorList {
+	if budget != nil && budget.MinAvailable != nil && budget.MaxUnavailable != nil {
+		return field.ErrorList{field.Forbidden(path, "only one of minAvailable or maxUnavailable may be set")}
+	}
+	return nil
+}
+
+// validateBackup checks that a backup has a cron schedule and exactly one complete target
+func validateBackup(path *field.Path, backup *BackupSpec) field.ErrorList {
+	allErrs := field.ErrorList{}
+	schedule := strings.TrimSpace(backup.Schedule)
+	if len(strings.Fields(schedule)) != 5 && !strings.HasPrefix(schedule, "@") {
+		allErrs = append(allErrs, field.Invalid(path.Child("schedule"), backup.Schedule, "must be a cron schedule of 5 fields or a predefined schedule such as @daily"))
+	}
+
+	allErrs = append(allErrs, validateBackupTarget(path.Child("target"), backup.Target)...)
+	return allErrs
+}
+
+// validateRestore checks that a restore names a backup and exactly one complete source
+func validateRestore(path *field.Path, restore *RestoreSpec) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if restore.Backup == "" || strings.Contains(restore.Backup, "/") {
+		allErrs = append(allErrs, field.Invalid(path.Child("backup"), restore.Backup, "must be the name of a backup"))
+	}
+	allErrs = append(allErrs, validateBackupTarget(path.Child("source"), restore.Source)...)
+	return allErrs
+}
+
+// validateBackupTarget checks that exactly one complete target is set
+func validateBackupTarget(targetPath *field.Path, target BackupTarget) field.ErrorList {
+	allErrs := field.ErrorList{}
+	switch {
+	case target.PersistentVolumeClaim == nil && target.S3 == nil:
+		allErrs = append(allErrs, field.Required(targetPath, "one of persistentVolumeClaim or s3 is required"))
+	case target.PersistentVolumeClaim != nil && target.S3 != nil:
+		allErrs = append(allErrs, field.Forbidden(targetPath, "only one of persistentVolumeClaim or s3 may be set"))
+	case target.PersistentVolumeClaim != nil:
+		if target.PersistentVolumeClaim.ClaimName == "" {
+			allErrs = append(allErrs, field.Required(targetPath.Child("persistentVolumeClaim", "claimName"), ""))
+		}
+	default:
+		s3Path := targetPath.Child("s3")
+		if target.S3.Bucket == "" {
+			allErrs = append(allErrs, field.Required(s3Path.Child("bucket"), ""))
+		}
+		if target.S3.CredentialsSecretName == "" {
+			allErrs = append(allErrs, field.Required(s3Path.Child("credentialsSecretName"), ""))
+		}
+	}
+	return allErrs
+}
+
+// validateExposure checks that the node ports pinned by an exposure are in the node port range
+func validateExposure(path *field.Path, exposure *ServiceExposure) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if exposure == nil {
+		return allErrs
+	}
+	for service, port := range exposure.NodePorts {
+		if port < minNodePort || port > maxNodePort {
+			allErrs = append(allErrs, field.Invalid(path.Child("nodePorts").Key(service), port,
+				fmt.Sprintf("must be in the node port range %d-%d", minNodePort, maxNodePort)))
+		}
+	}
+	return allErrs
+}
+
+// nodePorts returns the node ports the Services of the app are pinned to by Service name. In
+// NodeIP mode the data tier takes the LegacyNodePorts it does not override
+func (r *HotelReservationApp) nodePorts() map[string]int32 {
+	ports := map[string]int32{}
+	exposure := r.Spec.Exposure
+	if exposure == nil {
+		exposure = &ExposureSpec{}
+	}
+
+	data := exposure.Data
+	if data == nil {
+		data = &ServiceExposure{}
+	}
+	if data.Type != corev1.ServiceTypeClusterIP && (data.Type != "" || r.Spec.NetworkMode == NetworkModeNodeIP) {
+		if r.Spec.NetworkMode == NetworkModeNodeIP {
+			for service, port := range LegacyNodePorts {
+				ports[service] = port
+			}
+		}
+		addNodePorts(ports, data)
+	}
+	if exposure.Logic != nil && exposure.Logic.Type != "" && exposure.Logic.Type != corev1.ServiceTypeClusterIP {
+		addNodePorts(ports, exposure.Logic)
+	}
+	if exposure.Frontend != nil && exposure.Frontend.Type != "" && exposure.Frontend.Type != corev1.ServiceTypeClusterIP {
+		addNodePorts(ports, &exposure.Frontend.ServiceExposure)
+	}
+	return ports
+}
+
+// addNodePorts adds the node ports pinned by an exposure to ports
+func addNodePorts(ports map[string]int32, exposure *ServiceExposure) {
+	for service, port := range exposure.NodePorts {
+		ports[service] = port
+	}
+}
+
+// nodePortCollisions reports the node ports of the app that are already used by another
+// HotelReservationApp, in any namespace since node ports are shared by the whole cluster
+func (v *hotelReservationAppValidator) nodePortCollisions(ctx context.Context, app *HotelReservationApp) (field.ErrorList, error) {
+	allErrs := field.ErrorList{}
+	ports := map[int32]string{}
+	for service, port := range app.nodePorts() {
+		ports[port] = service
+	}
+	if len(ports) == 0 {
+		return allErrs, nil
+	}
+
+	apps := &HotelReservationAppList{}
+	if err := v.reader.List(ctx, apps); err != nil {
+		return nil, err
+	}
+	for _, other := range apps.Items {
+		if other.Namespace == app.Namespace && other.Name == app.Name {
+			continue
+		}
+		for otherService, port := range other.nodePorts() {
+			if service, ok := ports[port]; ok {
+				allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "exposure"),
+					fmt.Sprintf("node port %d of %s is already used by %s of HotelReservationApp %s/%s", port, service, otherService, other.Namespace, other.Name)))
+			}
+		}
+	}
+	return allErrs, nil
+}
+
+// missingNodes returns a warning for each node named in the spec that does not exist, the pods
+// pinned to it would stay Pending. A node that cannot be read is only warned about too, so that
+// a transient API error never blocks admission
+func (v *hotelReservationAppValidator) missingNodes(ctx context.Context, app *HotelReservationApp) []string {
+	warnings := []string{}
+	checked := map[string]struct{}{"": {}}
+	for _, nodeName := range []string{app.Spec.LogicNodeName, app.Spec.DataNodeName} {
+		if _, ok := checked[nodeName]; ok {
+			continue
+		}
+		checked[nodeName] = struct{}{}
+		err := v.reader.Get(ctx, types.NamespacedName{Name: nodeName}, &corev1.Node{})
+		if errors.IsNotFound(err) {
+			warnings = append(warnings, fmt.Sprintf("node %s does not exist, the pods scheduled on it will stay Pending", nodeName))
+		} else if err != nil {
+			hotelreservationapplog.Error(err, "failed to check node", "node", nodeName)
+			warnings = append(warnings, fmt.Sprintf("could not check that node %s exists: %s", nodeName, err))
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,356 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// newFakeReader returns a client serving the given objects
+func newFakeReader(objects ...client.Object) client.Reader {
+	scheme := runtime.NewScheme()
+	Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+	Expect(AddToScheme(scheme)).To(Succeed())
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+}
+
+// failingReader is a client whose reads all fail
+type failingReader struct {
+	client.Reader
+}
+
+func (failingReader) Get(context.Context, client.ObjectKey, client.Object) error {
+	return fmt.Errorf("connection refused")
+}
+
+// newNode returns a Node with the given internal IP
+func newNode(name, internalIP string) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.NodeStatus{
+			Addresses: []corev1.NodeAddress{
+				{Type: corev1.NodeHostName, Address: name},
+				{Type: corev1.NodeInternalIP, Address: internalIP},
+			},
+		},
+	}
+}
+
+// newApp returns a HotelReservationApp with the given spec
+func newApp(namespace, name string, spec HotelReservationAppSpec) *HotelReservationApp {
+	return &HotelReservationApp{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       spec,
+	}
+}
+
+// intOrStringPtr returns a pointer to the given value
+func intOrStringPtr(value intstr.IntOrString) *intstr.IntOrString {
+	return &value
+}
+
+// quantityPtr returns a pointer to the parsed quantity
+func quantityPtr(quantity string) *resource.Quantity {
+	parsed := resource.MustParse(quantity)
+	return &parsed
+}
+
+// errorsOf returns the field and type of each error so that they can be compared
+func errorsOf(allErrs field.ErrorList) []string {
+	described := []string{}
+	for _, err := range allErrs {
+		described = append(described, fmt.Sprintf("%s: %s", err.Field, err.Type))
+	}
+	return described
+}
+
+var _ = Describe("HotelReservationApp webhook", func() {
+	Describe("Default", func() {
+		It("fills in the network mode, the deletion policy and the ingress path", func() {
+			app := newApp("hotel", "hotel", HotelReservationAppSpec{
+				Exposure: &ExposureSpec{Frontend: &FrontendExposure{Ingress: &IngressSpec{}}},
+			})
+			defaulter := &hotelReservationAppDefaulter{reader: newFakeReader()}
+			Expect(defaulter.Default(context.TODO(), app)).To(Succeed())
+			Expect(app.Spec.NetworkMode).To(Equal(NetworkModeInCluster))
+			Expect(app.Spec.DeletionPolicy).To(Equal(DeletionPolicyRetain))
+			Expect(app.Spec.Exposure.Frontend.Ingress.Path).To(Equal("/"))
+		})
+
+		It("keeps the fields that are set and trims the node names and IPs", func() {
+			app := newApp("hotel", "hotel", HotelReservationAppSpec{
+				NetworkMode:    NetworkModeNodeIP,
+				DeletionPolicy: DeletionPolicyDelete,
+				LogicNodeName:  " logic ",
+				LogicNodeIp:    " 10.0.0.1 ",
+				DataNodeName:   "data\n",
+				DataNodeIp:     "10.0.0.2\t",
+			})
+			defaulter := &hotelReservationAppDefaulter{reader: newFakeReader()}
+			Expect(defaulter.Default(context.TODO(), app)).To(Succeed())
+			Expect(app.Spec.NetworkMode).To(Equal(NetworkModeNodeIP))
+			Expect(app.Spec.DeletionPolicy).To(Equal(DeletionPolicyDelete))
+			Expect(app.Spec.LogicNodeName).To(Equal("logic"))
+			Expect(app.Spec.LogicNodeIp).To(Equal("10.0.0.1"))
+			Expect(app.Spec.DataNodeName).To(Equal("data"))
+			Expect(app.Spec.DataNodeIp).To(Equal("10.0.0.2"))
+		})
+
+		It("fills in the node IPs from the internal IPs of the nodes in NodeIP mode", func() {
+			app := newApp("hotel", "hotel", HotelReservationAppSpec{
+				NetworkMode:   NetworkModeNodeIP,
+				LogicNodeName: "logic",
+				DataNodeName:  "missing",
+			})
+			defaulter := &hotelReservationAppDefaulter{reader: newFakeReader(newNode("logic", "10.0.0.1"))}
+			Expect(defaulter.Default(context.TODO(), app)).To(Succeed())
+			Expect(app.Spec.LogicNodeIp).To(Equal("10.0.0.1"))
+			Expect(app.Spec.DataNodeIp).To(BeEmpty())
+		})
+
+		It("leaves the node IPs empty in InCluster mode", func() {
+			app := newApp("hotel", "hotel", HotelReservationAppSpec{LogicNodeName: "logic"})
+			defaulter := &hotelReservationAppDefaulter{reader: newFakeReader(newNode("logic", "10.0.0.1"))}
+			Expect(defaulter.Default(context.TODO(), app)).To(Succeed())
+			Expect(app.Spec.LogicNodeIp).To(BeEmpty())
+		})
+	})
+
+	DescribeTable("validateSpec",
+		func(spec HotelReservationAppSpec, expected []string) {
+			Expect(errorsOf(newApp("hotel", "hotel", spec).validateSpec())).To(Equal(expected))
+		},
+		Entry("accepts an empty InCluster spec", HotelReservationAppSpec{NetworkMode: NetworkModeInCluster}, []string{}),
+		Entry("accepts a NodeIP spec with both node IPs",
+			HotelReservationAppSpec{NetworkMode: NetworkModeNodeIP, LogicNodeIp: "10.0.0.1", DataNodeIp: "fd00::2"}, []string{}),
+		Entry("requires the node IPs in NodeIP mode", HotelReservationAppSpec{NetworkMode: NetworkModeNodeIP},
+			[]string{"spec.logicNodeIp: Required value", "spec.dataNodeIp: Required value"}),
+		Entry("rejects node IPs that are not IP addresses",
+			HotelReservationAppSpec{LogicNodeIp: "10.0.0.256", DataNodeIp: "data"},
+			[]string{"spec.logicNodeIp: Invalid value", "spec.dataNodeIp: Invalid value"}),
+		Entry("rejects node names that are not DNS subdomains",
+			HotelReservationAppSpec{LogicNodeName: "Logic_Node", DataNodeName: "data"},
+			[]string{"spec.logicNodeName: Invalid value"}),
+		Entry("accepts node ports at both ends of the range",
+			HotelReservationAppSpec{Exposure: &ExposureSpec{Logic: &ServiceExposure{
+				Type:      corev1.ServiceTypeNodePort,
+				NodePorts: map[string]int32{"geo": 30000, "rate": 32767},
+			}}}, []string{}),
+		Entry("rejects node ports outside of the range",
+			HotelReservationAppSpec{Exposure: &ExposureSpec{
+				Data:     &ServiceExposure{NodePorts: map[string]int32{"mongodb-geo": 29999}},
+				Frontend: &FrontendExposure{ServiceExposure: ServiceExposure{NodePorts: map[string]int32{"frontend": 32768}}},
+			}}, []string{"spec.exposure.data.nodePorts[mongodb-geo]: Invalid value", "spec.exposure.frontend.nodePorts[frontend]: Invalid value"}),
+		Entry("rejects two Services pinned to the same node port",
+			HotelReservationAppSpec{Exposure: &ExposureSpec{
+				Logic:    &ServiceExposure{Type: corev1.ServiceTypeNodePort, NodePorts: map[string]int32{"geo": 30100}},
+				Frontend: &FrontendExposure{ServiceExposure: ServiceExposure{Type: corev1.ServiceTypeNodePort, NodePorts: map[string]int32{"frontend": 30100}}},
+			}}, []string{"spec.exposure: Duplicate value"}),
+		Entry("rejects a node port that is also a legacy data node port in NodeIP mode",
+			HotelReservationAppSpec{NetworkMode: NetworkModeNodeIP, LogicNodeIp: "10.0.0.1", DataNodeIp: "10.0.0.2", Exposure: &ExposureSpec{
+				Logic: &ServiceExposure{Type: corev1.ServiceTypeNodePort, NodePorts: map[string]int32{"geo": LegacyNodePorts["mongodb-geo"]}},
+			}}, []string{"spec.exposure: Duplicate value"}),
+		Entry("ignores the node ports of ClusterIP Services",
+			HotelReservationAppSpec{Exposure: &ExposureSpec{
+				Logic:    &ServiceExposure{Type: corev1.ServiceTypeClusterIP, NodePorts: map[string]int32{"geo": 30100}},
+				Frontend: &FrontendExposure{ServiceExposure: ServiceExposure{Type: corev1.ServiceTypeNodePort, NodePorts: map[string]int32{"frontend": 30100}}},
+			}}, []string{}),
+	)
+
+	Describe("nodePortCollisions", func() {
+		nodeIPApp := func(namespace, name string) *HotelReservationApp {
+			return newApp(namespace, name, HotelReservationAppSpec{NetworkMode: NetworkModeNodeIP, LogicNodeIp: "10.0.0.1", DataNodeIp: "10.0.0.2"})
+		}
+
+		It("reports the node ports used by another app in any namespace", func() {
+			validator := &hotelReservationAppValidator{reader: newFakeReader(nodeIPApp("other", "hotel"))}
+			collisions, err := validator.nodePortCollisions(context.TODO(), nodeIPApp("hotel", "hotel"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(collisions).To(HaveLen(len(LegacyNodePorts)))
+			Expect(collisions[0].Type).To(Equal(field.ErrorTypeForbidden))
+		})
+
+		It("ignores the app being validated", func() {
+			app := nodeIPApp("hotel", "hotel")
+			validator := &hotelReservationAppValidator{reader: newFakeReader(app.DeepCopy())}
+			Expect(validator.nodePortCollisions(context.TODO(), app)).To(BeEmpty())
+		})
+
+		It("ignores apps that do not pin the same node ports", func() {
+			other := newApp("hotel", "other", HotelReservationAppSpec{Exposure: &ExposureSpec{
+				Frontend: &FrontendExposure{ServiceExposure: ServiceExposure{Type: corev1.ServiceTypeNodePort, NodePorts: map[string]int32{"frontend": 30100}}},
+			}})
+			validator := &hotelReservationAppValidator{reader: newFakeReader(other)}
+			Expect(validator.nodePortCollisions(context.TODO(), nodeIPApp("hotel", "hotel"))).To(BeEmpty())
+		})
+
+		It("does not list the apps when the app pins no node ports", func() {
+			validator := &hotelReservationAppValidator{reader: failingReader{}}
+			Expect(validator.nodePortCollisions(context.TODO(), newApp("hotel", "hotel", HotelReservationAppSpec{}))).To(BeEmpty())
+		})
+	})
+
+	Describe("missingNodes", func() {
+		spec := HotelReservationAppSpec{LogicNodeName: "logic", DataNodeName: "data"}
+
+		It("warns about the nodes that do not exist", func() {
+			validator := &hotelReservationAppValidator{reader: newFakeReader(newNode("logic", "10.0.0.1"))}
+			warnings := validator.missingNodes(context.TODO(), newApp("hotel", "hotel", spec))
+			Expect(warnings).To(HaveLen(1))
+			Expect(warnings[0]).To(ContainSubstring("node data does not exist"))
+		})
+
+		It("warns instead of failing when a node cannot be read", func() {
+			validator := &hotelReservationAppValidator{reader: failingReader{}}
+			warnings := validator.missingNodes(context.TODO(), newApp("hotel", "hotel", spec))
+			Expect(warnings).To(HaveLen(2))
+			Expect(warnings[0]).To(ContainSubstring("could not check that node logic exists"))
+		})
+
+		It("checks a node named twice once", func() {
+			validator := &hotelReservationAppValidator{reader: newFakeReader()}
+			warnings := validator.missingNodes(context.TODO(), newApp("hotel", "hotel", HotelReservationAppSpec{LogicNodeName: "node", DataNodeName: "node"}))
+			Expect(warnings).To(HaveLen(1))
+		})
+	})
+	DescribeTable("validateBackup",
+		func(backup BackupSpec, expected []string) {
+			Expect(errorsOf(validateBackup(field.NewPath("backup"), &backup))).To(Equal(expected))
+		},
+		Entry("accepts a PersistentVolumeClaim target",
+			BackupSpec{Schedule: "0 2 * * *", Target: BackupTarget{PersistentVolumeClaim: &PVCBackupTarget{ClaimName: "backups"}}}, []string{}),
+		Entry("accepts an S3 target on a predefined schedule",
+			BackupSpec{Schedule: "@daily", Target: BackupTarget{S3: &S3BackupTarget{Bucket: "backups", CredentialsSecretName: "aws"}}}, []string{}),
+		Entry("rejects a schedule that is not a cron schedule",
+			BackupSpec{Schedule: "every day", Target: BackupTarget{PersistentVolumeClaim: &PVCBackupTarget{ClaimName: "backups"}}},
+			[]string{"backup.schedule: Invalid value"}),
+		Entry("requires a target", BackupSpec{Schedule: "0 2 * * *"}, []string{"backup.target: Required value"}),
+		Entry("forbids both targets",
+			BackupSpec{Schedule: "0 2 * * *", Target: BackupTarget{
+				PersistentVolumeClaim: &PVCBackupTarget{ClaimName: "backups"},
+				S3:                    &S3BackupTarget{Bucket: "backups", CredentialsSecretName: "aws"},
+			}}, []string{"backup.target: Forbidden"}),
+		Entry("requires the claim of a PersistentVolumeClaim target",
+			BackupSpec{Schedule: "0 2 * * *", Target: BackupTarget{PersistentVolumeClaim: &PVCBackupTarget{Path: "hotel"}}},
+			[]string{"backup.target.persistentVolumeClaim.claimName: Required value"}),
+		Entry("requires the bucket and credentials of an S3 target",
+			BackupSpec{Schedule: "0 2 * * *", Target: BackupTarget{S3: &S3BackupTarget{Endpoint: "https://minio:9000"}}},
+			[]string{"backup.target.s3.bucket: Required value", "backup.target.s3.credentialsSecretName: Required value"}),
+	)
+	DescribeTable("validateRestore",
+		func(restore RestoreSpec, expected []string) {
+			Expect(errorsOf(validateRestore(field.NewPath("restore"), &restore))).To(Equal(expected))
+		},
+		Entry("accepts a named backup of a PersistentVolumeClaim",
+			RestoreSpec{Backup: "20220101T000000Z", Source: BackupTarget{PersistentVolumeClaim: &PVCBackupTarget{ClaimName: "backups"}}}, []string{}),
+		Entry("requires the name of a backup",
+			RestoreSpec{Source: BackupTarget{PersistentVolumeClaim: &PVCBackupTarget{ClaimName: "backups"}}}, []string{"restore.backup: Invalid value"}),
+		Entry("rejects a path as the name of a backup",
+			RestoreSpec{Backup: "../20220101T000000Z", Source: BackupTarget{PersistentVolumeClaim: &PVCBackupTarget{ClaimName: "backups"}}},
+			[]string{"restore.backup: Invalid value"}),
+		Entry("requires a source", RestoreSpec{Backup: "20220101T000000Z"}, []string{"restore.source: Required value"}),
+	)
+
+	DescribeTable("validateUpdate",
+		func(old, updated *RestoreSpec, expected []string) {
+			app := newApp("hotel", "hotel", HotelReservationAppSpec{Restore: updated})
+			Expect(errorsOf(app.validateUpdate(newApp("hotel", "hotel", HotelReservationAppSpec{Restore: old})))).To(Equal(expected))
+		},
+		Entry("allows an app without a restore", nil, nil, []string{}),
+		Entry("allows an unchanged restore",
+			&RestoreSpec{Backup: "20220101T000000Z", Source: BackupTarget{PersistentVolumeClaim: &PVCBackupTarget{ClaimName: "backups"}}},
+			&RestoreSpec{Backup: "20220101T000000Z", Source: BackupTarget{PersistentVolumeClaim: &PVCBackupTarget{ClaimName: "backups"}}},
+			[]string{}),
+		Entry("allows removing the restore",
+			&RestoreSpec{Backup: "20220101T000000Z", Source: BackupTarget{PersistentVolumeClaim: &PVCBackupTarget{ClaimName: "backups"}}},
+			nil, []string{}),
+		Entry("forbids adding a restore", nil,
+			&RestoreSpec{Backup: "20220101T000000Z", Source: BackupTarget{PersistentVolumeClaim: &PVCBackupTarget{ClaimName: "backups"}}},
+			[]string{"spec.restore: Forbidden"}),
+		Entry("forbids changing the backup restored from",
+			&RestoreSpec{Backup: "20220101T000000Z", Source: BackupTarget{PersistentVolumeClaim: &PVCBackupTarget{ClaimName: "backups"}}},
+			&RestoreSpec{Backup: "20220102T000000Z", Source: BackupTarget{PersistentVolumeClaim: &PVCBackupTarget{ClaimName: "backups"}}},
+			[]string{"spec.restore: Forbidden"}),
+	)
+	DescribeTable("validateUpdate of the emptyDir mode",
+		func(old, updated HotelReservationAppSpec, expected []string) {
+			app := newApp("hotel", "hotel", updated)
+			Expect(errorsOf(app.validateUpdate(newApp("hotel", "hotel", old)))).To(Equal(expected))
+		},
+		Entry("allows resizing the volumes",
+			HotelReservationAppSpec{Storage: &StorageSpec{}},
+			HotelReservationAppSpec{Storage: &StorageSpec{Size: quantityPtr("5Gi")}}, []string{}),
+		Entry("allows an unchanged emptyDir mode",
+			HotelReservationAppSpec{Storage: &StorageSpec{EmptyDir: true}},
+			HotelReservationAppSpec{Storage: &StorageSpec{EmptyDir: true}}, []string{}),
+		Entry("forbids leaving the emptyDir mode",
+			HotelReservationAppSpec{Storage: &StorageSpec{EmptyDir: true}},
+			HotelReservationAppSpec{Storage: &StorageSpec{}}, []string{"spec.storage.emptyDir: Forbidden"}),
+		Entry("forbids entering the emptyDir mode", HotelReservationAppSpec{},
+			HotelReservationAppSpec{Storage: &StorageSpec{EmptyDir: true}}, []string{"spec.storage.emptyDir: Forbidden"}),
+		Entry("forbids changing the emptyDir mode of an instance", HotelReservationAppSpec{},
+			HotelReservationAppSpec{Components: map[string]ComponentSpec{"mongodb-geo": {Storage: &StorageSpec{EmptyDir: true}}}},
+			[]string{"spec.components[mongodb-geo].storage.emptyDir: Forbidden"}),
+		Entry("allows an instance override matching the emptyDir mode of the app",
+			HotelReservationAppSpec{Storage: &StorageSpec{EmptyDir: true}},
+			HotelReservationAppSpec{Storage: &StorageSpec{EmptyDir: true}, Components: map[string]ComponentSpec{"mongodb-geo": {Storage: &StorageSpec{EmptyDir: true}}}},
+			[]string{}),
+		Entry("ignores the storage of other components", HotelReservationAppSpec{},
+			HotelReservationAppSpec{Components: map[string]ComponentSpec{"geo": {Storage: &StorageSpec{EmptyDir: true}}}}, []string{}),
+	)
+	DescribeTable("validateAutoscaling",
+		func(component string, autoscaling AutoscalingSpec, nodeIPMode bool, expected []string) {
+			Expect(errorsOf(validateAutoscaling(field.NewPath("autoscaling"), component, &autoscaling, nodeIPMode))).To(Equal(expected))
+		},
+		Entry("accepts a logic service", "search", AutoscalingSpec{MinReplicas: pointer.Int32Ptr(2), MaxReplicas: 5}, false, []string{}),
+		Entry("accepts equal replica limits", "frontend", AutoscalingSpec{MinReplicas: pointer.Int32Ptr(3), MaxReplicas: 3}, false, []string{}),
+		Entry("accepts a missing minReplicas", "geo", AutoscalingSpec{MaxReplicas: 3}, false, []string{}),
+		Entry("forbids a mongodb instance", "mongodb-geo", AutoscalingSpec{MaxReplicas: 3}, false, []string{"autoscaling: Forbidden"}),
+		Entry("forbids a memcached instance", "memcached-rate", AutoscalingSpec{MaxReplicas: 3}, false, []string{"autoscaling: Forbidden"}),
+		Entry("forbids consul", "consul", AutoscalingSpec{MaxReplicas: 3}, false, []string{"autoscaling: Forbidden"}),
+		Entry("forbids jaeger", "jaeger", AutoscalingSpec{MaxReplicas: 3}, false, []string{"autoscaling: Forbidden"}),
+		Entry("forbids the NodeIP mode", "search", AutoscalingSpec{MaxReplicas: 3}, true, []string{"autoscaling: Forbidden"}),
+		Entry("rejects minReplicas above maxReplicas", "search", AutoscalingSpec{MinReplicas: pointer.Int32Ptr(4), MaxReplicas: 3}, false,
+			[]string{"autoscaling.maxReplicas: Invalid value"}),
+	)
+	DescribeTable("validateDisruptionBudget",
+		func(budget *DisruptionBudgetSpec, expected []string) {
+			Expect(errorsOf(validateDisruptionBudget(field.NewPath("disruptionBudget"), budget))).To(Equal(expected))
+		},
+		Entry("accepts no budget", nil, []string{}),
+		Entry("accepts a minAvailable", &DisruptionBudgetSpec{MinAvailable: intOrStringPtr(intstr.FromInt(1))}, []string{}),
+		Entry("accepts a maxUnavailable", &DisruptionBudgetSpec{MaxUnavailable: intOrStringPtr(intstr.FromString("50%"))}, []string{}),
+		Entry("forbids both limits",
+			&DisruptionBudgetSpec{MinAvailable: intOrStringPtr(intstr.FromInt(1)), MaxUnavailable: intOrStringPtr(intstr.FromInt(1))},
+			[]string{"disruptionBudget: Forbidden"}),
+	)
+})
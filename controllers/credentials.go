@@ -0,0 +1,199 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	examplev1beta1 "github.com/Youngpig1998/hotelreservation-operator/api/v1beta1"
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/bootstrap"
+	"github.com/Youngpig1998/hotelreservation-operator/internal/operator"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// reconcileCredentials reconciles the credentials Secret of every mongodb instance and, while
+// its passwords are pending, the Job creating or updating its users with them. The pending
+// passwords become the current ones once the Job has succeeded. The services' user they replace
+// stays valid until every logic Deployment runs with the config holding the new one, then a
+// second Job drops it. It returns the active credentials of the instances whose users exist,
+// along with the errors of the instances whose Job failed
+func (r *HotelReservationAppReconciler) reconcileCredentials(ctx context.Context, app *examplev1beta1.HotelReservationApp, bootstrapClient *bootstrap.Client) (operator.MongoCredentials, map[string]error, error) {
+	credentials := operator.MongoCredentials{}
+	credentialsErrors := map[string]error{}
+	if !operator.AuthEnabled(app) {
+		return credentials, credentialsErrors, nil
+	}
+
+	type instanceCredentials struct {
+		name     string
+		data     map[string][]byte
+		rotation int64
+	}
+	instances := []instanceCredentials{}
+	for _, component := range operator.EnabledComponents(app) {
+		if !component.NeedsDB {
+			continue
+		}
+		name := component.DBName()
+
+		data, rotation, err := r.credentialsData(ctx, app, name)
+		if err != nil {
+			return nil, nil, err
+		}
+		err = bootstrapClient.CreateResource(operator.CredentialsSecretName(name), operator.CredentialsSecret(app, name, data, rotation))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if _, pending := data[operator.PendingPasswordKey]; pending {
+			jobName := operator.CredentialsJobName(name, rotation)
+			err = bootstrapClient.CreateResource(jobName, operator.CredentialsJob(app, name, rotation))
+			if err != nil {
+				return nil, nil, err
+			}
+			succeeded, err := r.jobSucceeded(ctx, app.Namespace, jobName)
+			if err != nil {
+				credentialsErrors[name] = err
+			} else if succeeded {
+				r.Log.Info("Users updated, promoting the pending credentials", "Name", name, "Rotation", rotation)
+				operator.PromoteCredentialsData(data)
+				err = bootstrapClient.CreateResource(operator.CredentialsSecretName(name), operator.CredentialsSecret(app, name, data, rotation))
+				if err != nil {
+					return nil, nil, err
+				}
+			}
+		}
+
+		if active, ok := operator.CredentialsFromData(data); ok {
+			credentials[name] = active
+		}
+		instances = append(instances, instanceCredentials{name: name, data: data, rotation: rotation})
+	}
+
+	//The previous services' users are only dropped once no logic pod runs with them anymore
+	rolledOut, err := r.configRolledOut(ctx, app, operator.ConfigHash(operator.ServiceConfig(app, credentials)))
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, instance := range instances {
+		if _, previous := instance.data[operator.PreviousUsernameKey]; !previous || !rolledOut {
+			continue
+		}
+		jobName := operator.CredentialsCleanupJobName(instance.name, instance.rotation)
+		err = bootstrapClient.CreateResource(jobName, operator.CredentialsCleanupJob(app, instance.name, instance.rotation))
+		if err != nil {
+			return nil, nil, err
+		}
+		succeeded, err := r.jobSucceeded(ctx, app.Namespace, jobName)
+		if err != nil {
+			credentialsErrors[instance.name] = err
+		} else if succeeded {
+			r.Log.Info("Previous user dropped", "Name", instance.name, "Rotation", instance.rotation)
+			operator.ForgetPreviousCredentialsData(instance.data)
+			err = bootstrapClient.CreateResource(operator.CredentialsSecretName(instance.name), operator.CredentialsSecret(app, instance.name, instance.data, instance.rotation))
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	return credentials, credentialsErrors, nil
+}
+
+// configRolledOut returns whether every logic Deployment runs with the config of the given hash,
+// with all of its replicas updated and available. A Deployment that does not exist yet will be
+// created with that config
+func (r *HotelReservationAppReconciler) configRolledOut(ctx context.Context, app *examplev1beta1.HotelReservationApp, hash string) (bool, error) {
+	for _, component := range operator.EnabledComponents(app) {
+		deployment := &appsv1.Deployment{}
+		err := r.Get(ctx, types.NamespacedName{Name: component.Name, Namespace: app.Namespace}, deployment)
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return false, err
+		}
+		if deployment.Spec.Template.Annotations[operator.ConfigHashAnnotation] != hash {
+			return false, nil
+		}
+		updated := deployment.Status.UpdatedReplicas
+		if deployment.Status.ObservedGeneration < deployment.Generation || updated != replicasOrDefault(deployment.Spec.Replicas) ||
+			deployment.Status.Replicas != updated || deployment.Status.AvailableReplicas != updated {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// credentialsData returns the content of the credentials Secret of a mongodb instance and the
+// rotation its passwords belong to. New pending passwords are generated for a new instance and
+// when the spec asks for a later rotation than the one of the current passwords
+func (r *HotelReservationAppReconciler) credentialsData(ctx context.Context, app *examplev1beta1.HotelReservationApp, name string) (map[string][]byte, int64, error) {
+	rotation := operator.CredentialsRotation(app)
+
+	secret := &corev1.Secret{}
+	err := r.Get(ctx, types.NamespacedName{Name: operator.CredentialsSecretName(name), Namespace: app.Namespace}, secret)
+	if errors.IsNotFound(err) {
+		data, err := operator.NewCredentialsData()
+		return data, rotation, err
+	} else if err != nil {
+		return nil, 0, err
+	}
+
+	data := map[string][]byte{}
+	for key, value := range secret.Data {
+		data[key] = value
+	}
+	current, err := strconv.ParseInt(secret.Annotations[operator.CredentialsRotationAnnotation], 10, 64)
+	if err != nil {
+		current = -1
+	}
+	// A new rotation waits for the previous services' user of the last one to be dropped, its
+	// username is the one the new rotation switches to
+	_, pending := data[operator.PendingPasswordKey]
+	_, previous := data[operator.PreviousUsernameKey]
+	if pending || previous || current >= rotation {
+		return data, current, nil
+	}
+
+	r.Log.Info("Rotating credentials", "Name", name, "Rotation", rotation)
+	if err := operator.RotateCredentialsData(data); err != nil {
+		return nil, 0, err
+	}
+	return data, rotation, nil
+}
+
+// jobSucceeded returns whether the named Job has succeeded, and an error once it has failed
+func (r *HotelReservationAppReconciler) jobSucceeded(ctx context.Context, namespace string, name string) (bool, error) {
+	job := &batchv1.Job{}
+	err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, job)
+	if errors.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue {
+			return false, fmt.Errorf("job %s failed: %s", name, condition.Message)
+		}
+	}
+	return job.Status.Succeeded > 0, nil
+}
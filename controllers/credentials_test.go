@@ -0,0 +1,123 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	examplev1beta1 "github.com/Youngpig1998/hotelreservation-operator/api/v1beta1"
+	"github.com/Youngpig1998/hotelreservation-operator/internal/operator"
+)
+
+// frontendDeployment returns the frontend Deployment running the config of the given hash
+// with the given status
+func frontendDeployment(hash string, status appsv1.DeploymentStatus) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "frontend", Namespace: "hotel", Generation: 2},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: pointer.Int32Ptr(2),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{operator.ConfigHashAnnotation: hash}},
+			},
+		},
+		Status: status,
+	}
+}
+
+func TestConfigRolledOut(t *testing.T) {
+	rolledOut := appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2}
+	tests := []struct {
+		name       string
+		deployment *appsv1.Deployment
+		want       bool
+	}{
+		{"rolled out with the config", frontendDeployment("new", rolledOut), true},
+		{"missing deployments are not waited on", nil, true},
+		{"still runs the previous config", frontendDeployment("old", rolledOut), false},
+		{"generation not observed yet", frontendDeployment("new",
+			appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2}), false},
+		{"old pods still running", frontendDeployment("new",
+			appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 3, UpdatedReplicas: 2, AvailableReplicas: 3}), false},
+		{"new pods not available yet", frontendDeployment("new",
+			appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 1}), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects := []client.Object{}
+			if tt.deployment != nil {
+				objects = append(objects, tt.deployment)
+			}
+			r := newTestReconciler(t, objects...)
+			got, err := r.configRolledOut(context.Background(), testApp(nil), "new")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCredentialsData(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         map[string][]byte
+		wantRotation int64
+		wantPending  bool
+	}{
+		{"rotates the credentials", map[string][]byte{
+			operator.UsernameKey: []byte("hotelreservation"), operator.PasswordKey: []byte("password"),
+		}, 2, true},
+		{"waits for the previous user to be dropped", map[string][]byte{
+			operator.UsernameKey: []byte("hotelreservation"), operator.PasswordKey: []byte("password"),
+			operator.PreviousUsernameKey: []byte("hotelreservation-rotated"),
+		}, 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := testApp(nil)
+			app.Spec.MongoDB = &examplev1beta1.MongoDBSpec{Auth: &examplev1beta1.MongoDBAuthSpec{Rotation: 2}}
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        operator.CredentialsSecretName("mongodb-geo"),
+					Namespace:   "hotel",
+					Annotations: map[string]string{operator.CredentialsRotationAnnotation: "1"},
+				},
+				Data: tt.data,
+			}
+			r := newTestReconciler(t, secret)
+			data, rotation, err := r.credentialsData(context.Background(), app, "mongodb-geo")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rotation != tt.wantRotation {
+				t.Errorf("got rotation %d, want %d", rotation, tt.wantRotation)
+			}
+			if _, pending := data[operator.PendingPasswordKey]; pending != tt.wantPending {
+				t.Errorf("got pending %t, want %t", pending, tt.wantPending)
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	examplev1beta1 "github.com/Youngpig1998/hotelreservation-operator/api/v1beta1"
+	"github.com/Youngpig1998/hotelreservation-operator/internal/operator"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// teardownFinalizer holds the deletion of a HotelReservationApp until its deletionPolicy has
+// been applied to the PersistentVolumeClaims of its mongodb instances
+const teardownFinalizer = "example.njtech.edu.cn/teardown"
+
+// snapshotRequeueInterval is how long to wait before checking again on VolumeSnapshots that
+// are not ready to use yet
+var snapshotRequeueInterval = 10 * time.Second
+
+// volumeSnapshotGVK is the kind of the snapshots taken by the Snapshot deletion policy. It is
+// handled as unstructured so that the operator does not depend on the snapshot client
+var volumeSnapshotGVK = schema.GroupVersionKind{Group: "snapshot.storage.k8s.io", Version: "v1", Kind: "VolumeSnapshot"}
+
+// ensureFinalizer adds the teardown finalizer to the HotelReservationApp if it is missing
+func (r *HotelReservationAppReconciler) ensureFinalizer(ctx context.Context, app *examplev1beta1.HotelReservationApp) error {
+	if controllerutil.ContainsFinalizer(app, teardownFinalizer) {
+		return nil
+	}
+	controllerutil.AddFinalizer(app, teardownFinalizer)
+	return r.Update(ctx, app)
+}
+
+// finalize applies the deletionPolicy of a HotelReservationApp being deleted, then removes the
+// teardown finalizer so that the app and the resources it owns are garbage collected
+func (r *HotelReservationAppReconciler) finalize(ctx context.Context, app *examplev1beta1.HotelReservationApp) (ctrl.Result, error) {
+	log := r.Log.WithValues("hotelreservation", types.NamespacedName{Name: app.Name, Namespace: app.Namespace})
+	if !controllerutil.ContainsFinalizer(app, teardownFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	claims, err := r.databaseClaims(ctx, app)
+	if err != nil {
+		log.Error(err, "failed to list the mongodb PersistentVolumeClaims")
+		return ctrl.Result{}, err
+	}
+
+	switch app.Spec.DeletionPolicy {
+	case examplev1beta1.DeletionPolicySnapshot:
+		available, err := r.snapshotsAvailable()
+		if err != nil {
+			log.Error(err, "failed to look up the VolumeSnapshot kind")
+			return ctrl.Result{}, err
+		}
+		if !available {
+			log.Info("VolumeSnapshots are not available, retaining the mongodb PersistentVolumeClaims", "Count", len(claims))
+			if err := r.reportSnapshotsUnavailable(ctx, app); err != nil {
+				log.Error(err, "failed to update HotelReservationApp status")
+				return ctrl.Result{}, err
+			}
+			break
+		}
+		stopped, err := r.stopDatabases(ctx, app)
+		if err != nil {
+			log.Error(err, "failed to scale down the mongodb StatefulSets")
+			return ctrl.Result{}, err
+		}
+		if !stopped {
+			log.Info("Waiting for the mongodb instances to stop before snapshotting their volumes")
+			return ctrl.Result{RequeueAfter: snapshotRequeueInterval}, nil
+		}
+		ready, err := r.snapshotClaims(ctx, app, claims)
+		if err != nil {
+			log.Error(err, "failed to snapshot the mongodb PersistentVolumeClaims")
+			return ctrl.Result{}, err
+		}
+		if !ready {
+			log.Info("Waiting for the VolumeSnapshots of the mongodb PersistentVolumeClaims to become ready")
+			return ctrl.Result{RequeueAfter: snapshotRequeueInterval}, nil
+		}
+		if err := r.deleteClaims(ctx, claims); err != nil {
+			log.Error(err, "failed to delete the mongodb PersistentVolumeClaims")
+			return ctrl.Result{}, err
+		}
+	case examplev1beta1.DeletionPolicyDelete:
+		if err := r.deleteClaims(ctx, claims); err != nil {
+			log.Error(err, "failed to delete the mongodb PersistentVolumeClaims")
+			return ctrl.Result{}, err
+		}
+	default:
+		log.Info("Retaining the mongodb PersistentVolumeClaims", "Count", len(claims))
+	}
+
+	controllerutil.RemoveFinalizer(app, teardownFinalizer)
+	if err := r.Update(ctx, app); err != nil {
+		log.Error(err, "failed to remove the teardown finalizer")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// snapshotsAvailable returns whether the VolumeSnapshot kind is served by the cluster, it is
+// missing when the snapshot CRDs are not installed
+func (r *HotelReservationAppReconciler) snapshotsAvailable() (bool, error) {
+	_, err := r.RESTMapper().RESTMapping(volumeSnapshotGVK.GroupKind(), volumeSnapshotGVK.Version)
+	if meta.IsNoMatchError(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// reportSnapshotsUnavailable sets the Degraded condition of a HotelReservationApp whose Snapshot
+// deletion policy falls back to Retain because VolumeSnapshots are not available
+func (r *HotelReservationAppReconciler) reportSnapshotsUnavailable(ctx context.Context, app *examplev1beta1.HotelReservationApp) error {
+	meta.SetStatusCondition(&app.Status.Conditions, metav1.Condition{
+		Type:               examplev1beta1.ConditionDegraded,
+		Status:             metav1.ConditionTrue,
+		Reason:             "VolumeSnapshotsUnavailable",
+		Message:            "The VolumeSnapshot kind is not installed, the mongodb PersistentVolumeClaims are retained instead",
+		ObservedGeneration: app.Generation,
+	})
+	return r.Status().Update(ctx, app)
+}
+
+// stopDatabases scales the mongodb StatefulSets controlled by the app down to zero so that their
+// volumes are snapshotted at rest, and returns whether all of their pods are gone
+func (r *HotelReservationAppReconciler) stopDatabases(ctx context.Context, app *examplev1beta1.HotelReservationApp) (bool, error) {
+	stopped := true
+	for _, name := range operator.DatabaseNames() {
+		statefulSet, err := r.ownedStatefulSet(ctx, app, name)
+		if err != nil {
+			return false, err
+		}
+		if statefulSet == nil {
+			continue
+		}
+		if replicasOrDefault(statefulSet.Spec.Replicas) != 0 {
+			statefulSet.Spec.Replicas = pointer.Int32Ptr(0)
+			if err := r.Update(ctx, statefulSet); err != nil {
+				return false, err
+			}
+		}
+		if statefulSet.Status.ObservedGeneration < statefulSet.Generation || statefulSet.Status.Replicas != 0 {
+			stopped = false
+		}
+	}
+	return stopped, nil
+}
+
+// databaseClaims lists the PersistentVolumeClaims created from the volumeClaimTemplates of the
+// mongodb StatefulSets of the app. Only the StatefulSets controlled by the app are considered so
+// that the claims of another app of the namespace are left alone, the claims of a StatefulSet
+// that is already gone are retained
+func (r *HotelReservationAppReconciler) databaseClaims(ctx context.Context, app *examplev1beta1.HotelReservationApp) ([]corev1.PersistentVolumeClaim, error) {
+	owned := []corev1.PersistentVolumeClaim{}
+	for _, name := range operator.DatabaseNames() {
+		statefulSet, err := r.ownedStatefulSet(ctx, app, name)
+		if err != nil {
+			return nil, err
+		}
+		if statefulSet == nil {
+			continue
+		}
+		claims, err := r.claimsOf(ctx, statefulSet)
+		if err != nil {
+			return nil, err
+		}
+		owned = append(owned, claims...)
+	}
+	return owned, nil
+}
+
+// deleteClaims deletes the given PersistentVolumeClaims. Claims still mounted are only removed
+// by Kubernetes once the mongodb pods using them are gone
+func (r *HotelReservationAppReconciler) deleteClaims(ctx context.Context, claims []corev1.PersistentVolumeClaim) error {
+	for i := range claims {
+		if err := r.Delete(ctx, &claims[i]); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// snapshotClaims creates a VolumeSnapshot of each of the given PersistentVolumeClaims and
+// returns whether all of them are ready to use. The snapshots are not owned by the app so that
+// they outlive it, and are named after the app's UID so that a later app of the same name
+// takes its own snapshots
+func (r *HotelReservationAppReconciler) snapshotClaims(ctx context.Context, app *examplev1beta1.HotelReservationApp, claims []corev1.PersistentVolumeClaim) (bool, error) {
+	allReady := true
+	for _, claim := range claims {
+		name := fmt.Sprintf("%s-%.8s", claim.Name, app.UID)
+		snapshot := &unstructured.Unstructured{}
+		snapshot.SetGroupVersionKind(volumeSnapshotGVK)
+		err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: claim.Namespace}, snapshot)
+		if errors.IsNotFound(err) {
+			snapshot = newVolumeSnapshot(app, name, claim)
+			if err := r.Create(ctx, snapshot); err != nil {
+				return false, err
+			}
+		} else if err != nil {
+			return false, err
+		}
+
+		ready, _, _ := unstructured.NestedBool(snapshot.Object, "status", "readyToUse")
+		if !ready {
+			allReady = false
+		}
+		if message, found, _ := unstructured.NestedString(snapshot.Object, "status", "error", "message"); found {
+			return false, fmt.Errorf("snapshot %s of %s failed: %s", name, claim.Name, message)
+		}
+	}
+	return allReady, nil
+}
+
+// newVolumeSnapshot builds the VolumeSnapshot of a mongodb PersistentVolumeClaim
+func newVolumeSnapshot(app *examplev1beta1.HotelReservationApp, name string, claim corev1.PersistentVolumeClaim) *unstructured.Unstructured {
+	snapshot := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"source": map[string]interface{}{
+				"persistentVolumeClaimName": claim.Name,
+			},
+		},
+	}}
+	snapshot.SetGroupVersionKind(volumeSnapshotGVK)
+	snapshot.SetName(name)
+	snapshot.SetNamespace(claim.Namespace)
+	snapshot.SetLabels(claim.Labels)
+	snapshot.SetAnnotations(map[string]string{"example.njtech.edu.cn/hotelreservationapp": app.Name})
+	if app.Spec.VolumeSnapshotClassName != nil {
+		_ = unstructured.SetNestedField(snapshot.Object, *app.Spec.VolumeSnapshotClassName, "spec", "volumeSnapshotClassName")
+	}
+	return snapshot
+}
@@ -0,0 +1,259 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	examplev1beta1 "github.com/Youngpig1998/hotelreservation-operator/api/v1beta1"
+)
+
+// newTestReconciler returns a reconciler backed by a fake client holding the given objects
+func newTestReconciler(t *testing.T, objects ...client.Object) *HotelReservationAppReconciler {
+	return newTestReconcilerWithMapper(t, meta.NewDefaultRESTMapper(nil), objects...)
+}
+
+// newTestReconcilerWithMapper returns a reconciler backed by a fake client holding the given
+// objects and serving the kinds of mapper
+func newTestReconcilerWithMapper(t *testing.T, mapper meta.RESTMapper, objects ...client.Object) *HotelReservationAppReconciler {
+	testScheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(testScheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := examplev1beta1.AddToScheme(testScheme); err != nil {
+		t.Fatal(err)
+	}
+	return &HotelReservationAppReconciler{
+		Client: fake.NewClientBuilder().WithScheme(testScheme).WithRESTMapper(mapper).WithObjects(objects...).Build(),
+		Scheme: testScheme,
+		Log:    logr.Discard(),
+	}
+}
+
+// claim returns a PersistentVolumeClaim of the hotel namespace labelled for the given service
+func claim(name string, service string) *corev1.PersistentVolumeClaim {
+	return &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{
+		Name:      name,
+		Namespace: "hotel",
+		Labels:    map[string]string{"io.kompose.service": service},
+	}}
+}
+
+// databaseStatefulSet returns the mongodb-geo StatefulSet with a geo volumeClaimTemplate, controlled by
+// the object of the given UID
+func databaseStatefulSet(controller types.UID) *appsv1.StatefulSet {
+	return &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mongodb-geo",
+			Namespace: "hotel",
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: examplev1beta1.GroupVersion.String(),
+				Kind:       "HotelReservationApp",
+				Name:       "hotel",
+				UID:        controller,
+				Controller: pointer.BoolPtr(true),
+			}},
+		},
+		Spec: appsv1.StatefulSetSpec{
+			VolumeClaimTemplates: []corev1.PersistentVolumeClaim{{ObjectMeta: metav1.ObjectMeta{Name: "geo"}}},
+		},
+	}
+}
+
+// claimNames returns the names of the PersistentVolumeClaims left in the hotel namespace
+func claimNames(t *testing.T, r *HotelReservationAppReconciler) []string {
+	claims := &corev1.PersistentVolumeClaimList{}
+	if err := r.List(context.Background(), claims, client.InNamespace("hotel")); err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, claim := range claims.Items {
+		names = append(names, claim.Name)
+	}
+	return names
+}
+
+func TestFinalize(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy examplev1beta1.DeletionPolicy
+		want   []string
+	}{
+		{"retains the claims by default", "", []string{"geo-mongodb-geo-0", "other-claim"}},
+		{"retains the claims", examplev1beta1.DeletionPolicyRetain, []string{"geo-mongodb-geo-0", "other-claim"}},
+		{"deletes the mongodb claims only", examplev1beta1.DeletionPolicyDelete, []string{"other-claim"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := testApp(nil)
+			app.UID = "hotel-uid"
+			app.Finalizers = []string{teardownFinalizer}
+			app.Spec.DeletionPolicy = tt.policy
+			r := newTestReconciler(t, app, databaseStatefulSet(app.UID), claim("geo-mongodb-geo-0", "mongodb-geo"), claim("other-claim", "other"))
+
+			if _, err := r.finalize(context.Background(), app); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if names := claimNames(t, r); !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("got claims %v, want %v", names, tt.want)
+			}
+			if len(app.Finalizers) != 0 {
+				t.Errorf("finalizer not removed: %v", app.Finalizers)
+			}
+		})
+	}
+}
+
+func TestFinalizeSnapshot(t *testing.T) {
+	snapshotMapper := meta.NewDefaultRESTMapper(nil)
+	snapshotMapper.Add(volumeSnapshotGVK, meta.RESTScopeNamespace)
+	running := databaseStatefulSet("hotel-uid")
+	running.Spec.Replicas = pointer.Int32Ptr(1)
+	running.Status.Replicas = 1
+	stopped := databaseStatefulSet("hotel-uid")
+	stopped.Spec.Replicas = pointer.Int32Ptr(0)
+
+	tests := []struct {
+		name          string
+		mapper        meta.RESTMapper
+		statefulSet   *appsv1.StatefulSet
+		wantRequeue   bool
+		wantSnapshot  bool
+		wantDegraded  bool
+		wantFinalizer bool
+	}{
+		{"retains the claims when VolumeSnapshots are not installed", meta.NewDefaultRESTMapper(nil), running, false, false, true, false},
+		{"scales the mongodb instances down before snapshotting", snapshotMapper, running, true, false, false, true},
+		{"snapshots the claims of stopped instances", snapshotMapper, stopped, true, true, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := testApp(nil)
+			app.UID = "hotel-uid"
+			app.Finalizers = []string{teardownFinalizer}
+			app.Spec.DeletionPolicy = examplev1beta1.DeletionPolicySnapshot
+			r := newTestReconcilerWithMapper(t, tt.mapper, app, tt.statefulSet.DeepCopy(), claim("geo-mongodb-geo-0", "mongodb-geo"))
+
+			result, err := r.finalize(context.Background(), app)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if requeue := result.RequeueAfter != 0; requeue != tt.wantRequeue {
+				t.Errorf("got requeue %v, want %v", requeue, tt.wantRequeue)
+			}
+			if names := claimNames(t, r); !reflect.DeepEqual(names, []string{"geo-mongodb-geo-0"}) {
+				t.Errorf("got claims %v", names)
+			}
+			if finalizer := len(app.Finalizers) != 0; finalizer != tt.wantFinalizer {
+				t.Errorf("got finalizer %v, want %v", finalizer, tt.wantFinalizer)
+			}
+			if degraded := meta.IsStatusConditionTrue(app.Status.Conditions, examplev1beta1.ConditionDegraded); degraded != tt.wantDegraded {
+				t.Errorf("got degraded %v, want %v", degraded, tt.wantDegraded)
+			}
+
+			statefulSet := &appsv1.StatefulSet{}
+			if err := r.Get(context.Background(), types.NamespacedName{Name: "mongodb-geo", Namespace: "hotel"}, statefulSet); err != nil {
+				t.Fatal(err)
+			}
+			if tt.mapper == snapshotMapper && replicasOrDefault(statefulSet.Spec.Replicas) != 0 {
+				t.Errorf("got %d replicas, want the instance scaled down", replicasOrDefault(statefulSet.Spec.Replicas))
+			}
+			if tt.mapper == snapshotMapper {
+				snapshot := &unstructured.Unstructured{}
+				snapshot.SetGroupVersionKind(volumeSnapshotGVK)
+				err := r.Get(context.Background(), types.NamespacedName{Name: "geo-mongodb-geo-0-hotel-ui", Namespace: "hotel"}, snapshot)
+				if taken := err == nil; taken != tt.wantSnapshot {
+					t.Errorf("got snapshot taken %v (%v), want %v", taken, err, tt.wantSnapshot)
+				}
+			}
+		})
+	}
+}
+
+func TestDatabaseClaims(t *testing.T) {
+	claims := []client.Object{
+		claim("geo-mongodb-geo-0", "mongodb-geo"),
+		claim("geo-mongodb-geo-1", "mongodb-geo"),
+		claim("geo-mongodb-geo-backup", "mongodb-geo"),
+		claim("geo-mongodb-geo-extra-0", "mongodb-geo"),
+		claim("data-mongodb-geo-0", "mongodb-geo"),
+		claim("geo-mongodb-geo-2", "other"),
+	}
+	tests := []struct {
+		name    string
+		objects []client.Object
+		want    []string
+	}{
+		{"selects the claims of a controlled StatefulSet by template and ordinal",
+			[]client.Object{databaseStatefulSet("hotel-uid")}, []string{"geo-mongodb-geo-0", "geo-mongodb-geo-1"}},
+		{"leaves the claims of another app's StatefulSet", []client.Object{databaseStatefulSet("other-uid")}, []string{}},
+		{"retains the claims of a StatefulSet that is gone", nil, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := testApp(nil)
+			app.UID = "hotel-uid"
+			r := newTestReconciler(t, append(tt.objects, claims...)...)
+
+			owned, err := r.databaseClaims(context.Background(), app)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			names := []string{}
+			for _, claim := range owned {
+				names = append(names, claim.Name)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("got claims %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewVolumeSnapshot(t *testing.T) {
+	app := testApp(nil)
+	app.Spec.VolumeSnapshotClassName = pointer.StringPtr("csi-snapclass")
+
+	snapshot := newVolumeSnapshot(app, "geo-mongodb-geo-0-0123abcd", *claim("geo-mongodb-geo-0", "mongodb-geo"))
+	if snapshot.GroupVersionKind() != volumeSnapshotGVK || snapshot.GetNamespace() != "hotel" {
+		t.Errorf("unexpected snapshot %s in %s", snapshot.GroupVersionKind(), snapshot.GetNamespace())
+	}
+	if source, _, _ := unstructured.NestedString(snapshot.Object, "spec", "source", "persistentVolumeClaimName"); source != "geo-mongodb-geo-0" {
+		t.Errorf("got source %q", source)
+	}
+	if class, _, _ := unstructured.NestedString(snapshot.Object, "spec", "volumeSnapshotClassName"); class != "csi-snapclass" {
+		t.Errorf("got class %q", class)
+	}
+	if len(snapshot.GetOwnerReferences()) != 0 {
+		t.Errorf("snapshot must outlive the app, got owners %v", snapshot.GetOwnerReferences())
+	}
+}
@@ -18,10 +18,18 @@ package controllers
 
 import (
 	"context"
+	"sort"
+
 	examplev1beta1 "github.com/Youngpig1998/hotelreservation-operator/api/v1beta1"
 	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/bootstrap"
 	"github.com/Youngpig1998/hotelreservation-operator/internal/operator"
 	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/rest"
@@ -30,8 +38,6 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
-var servicesName [8]string = [8]string{"reservation", "rate", "profile", "geo", "recommendation", "user", "search", "frontend"}
-
 var (
 	controllerManagerName = "hotelReservation-operator-controller-manager"
 )
@@ -50,7 +56,17 @@ type HotelReservationAppReconciler struct {
 //+kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=batch,resources=cronjobs,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;update;patch;delete
+//+kubebuilder:rbac:groups=storage.k8s.io,resources=storageclasses,verbs=get;list;watch
+//+kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshots,verbs=get;list;watch;create
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -83,6 +99,16 @@ func (r *HotelReservationAppReconciler) Reconcile(ctx context.Context, req ctrl.
 		return ctrl.Result{}, err
 	}
 
+	//The deletionPolicy is applied to the mongodb volumes before the instance is allowed to go away
+	if !instance.DeletionTimestamp.IsZero() {
+		return r.finalize(ctx, instance)
+	}
+	err = r.ensureFinalizer(ctx, instance)
+	if err != nil {
+		log.Error(err, "failed to add the teardown finalizer")
+		return ctrl.Result{}, err
+	}
+
 	//Set the bootstrapClient's owner value as the webhook,so the resources we create then will be set reference to the webhook
 	//when the webhook cr is deleted,the resources(such as deployment.configmap,issuer...) we create will be deleted too
 	bootstrapClient, err := bootstrap.NewClient(r.Config, r.Scheme, controllerManagerName, instance)
@@ -91,74 +117,140 @@ func (r *HotelReservationAppReconciler) Reconcile(ctx context.Context, req ctrl.
 		return ctrl.Result{}, err
 	}
 
-	//We create memcached services first,include profile,rate,reservation
-	for i := 0; i < 3; i++ {
-		deployForMem := operator.DeploymentForMem(servicesName[i], instance)
-		deployForMemName := "memcached-" + servicesName[i]
+	components := operator.EnabledComponents(instance)
+
+	//We create the memcached instances first, one for each service needing a cache
+	for _, component := range components {
+		if !component.NeedsCache {
+			continue
+		}
+		deployForMem := operator.DeploymentForMem(component.Name, instance)
+		deployForMemName := component.CacheName()
 		err = bootstrapClient.CreateResource(deployForMemName, deployForMem)
 		if err != nil {
 			log.Error(err, "failed to create operator's memcached deployment", "Name", deployForMemName)
-			return ctrl.Result{}, err
+			return r.reconcileFailed(ctx, instance, deployForMemName, err)
+		}
+
+		//A memcached instance with several replicas runs as a StatefulSet instead of a Deployment
+		err = bootstrapClient.CreateResource(deployForMemName, operator.StatefulSetForMem(component.Name, instance))
+		if err != nil {
+			log.Error(err, "failed to create operator's memcached StatefulSet", "Name", deployForMemName)
+			return r.reconcileFailed(ctx, instance, deployForMemName, err)
 		}
 
-		var nodePort int32 = 0
-		if servicesName[i] == "rate" {
-			nodePort = 31001
-		} else if servicesName[i] == "profile" {
-			nodePort = 31002
-		} else {
-			nodePort = 31003
+		err = bootstrapClient.CreateResource(operator.HeadlessServiceName(deployForMemName), operator.CacheHeadlessService(instance, deployForMemName))
+		if err != nil {
+			log.Error(err, "failed to create operator's memcached headless Service", "Name", deployForMemName)
+			return r.reconcileFailed(ctx, instance, deployForMemName, err)
 		}
 
-		service := operator.Service(deployForMemName, 11211, 11211, nodePort)
+		service := operator.Service(instance, deployForMemName, operator.TierData, 11211, 11211)
 		err = bootstrapClient.CreateResource(deployForMemName, service)
 		if err != nil {
 			log.Error(err, "failed to create operator's memcached Service", "Name", deployForMemName)
-			return ctrl.Result{}, err
+			return r.reconcileFailed(ctx, instance, deployForMemName, err)
 		}
 
 	}
 
-	//Then we create mongodb services,include geo,user,profile,recommendation,rate,reservation
-	for i := 0; i < 6; i++ {
-		statefulSet := operator.StatefulSet(servicesName[i], instance)
-		statefulSetName := "mongodb-" + servicesName[i]
+	//In replica set mode the members of the mongodb instances share a keyfile
+	keyfile, err := r.keyfile(ctx, instance)
+	if err != nil {
+		log.Error(err, "failed to get operator's mongodb keyfile", "Name", operator.KeyfileSecretName)
+		return r.reconcileFailed(ctx, instance, operator.KeyfileSecretName, err)
+	}
+	err = bootstrapClient.CreateResource(operator.KeyfileSecretName, operator.KeyfileSecret(instance, keyfile))
+	if err != nil {
+		log.Error(err, "failed to create operator's mongodb keyfile Secret", "Name", operator.KeyfileSecretName)
+		return r.reconcileFailed(ctx, instance, operator.KeyfileSecretName, err)
+	}
+
+	//With authentication enabled the users of each mongodb instance are created, or updated when
+	//their credentials are rotated, before the instance and the services start using them
+	credentials, credentialsErrors, err := r.reconcileCredentials(ctx, instance, bootstrapClient)
+	if err != nil {
+		log.Error(err, "failed to reconcile operator's mongodb credentials")
+		return ctrl.Result{}, err
+	}
+
+	//Then we create the mongodb instances, one for each service needing a database
+	for _, component := range components {
+		if !component.NeedsDB {
+			continue
+		}
+		statefulSet := operator.StatefulSet(component.Name, instance, credentials)
+		statefulSetName := component.DBName()
+		err = r.orphanStatefulSetOnServiceNameChange(ctx, instance.Namespace, statefulSetName, statefulSet.GetResource().(*appsv1.StatefulSet).Spec.ServiceName)
+		if err != nil {
+			log.Error(err, "failed to recreate operator's mongodb StatefulSet", "Name", statefulSetName)
+			return r.reconcileFailed(ctx, instance, statefulSetName, err)
+		}
 		err = bootstrapClient.CreateResource(statefulSetName, statefulSet)
 		if err != nil {
 			log.Error(err, "failed to create operator's mongodb StatefulSet", "Name", statefulSetName)
-			return ctrl.Result{}, err
-		}
-
-		var nodePort int32 = 0
-		if servicesName[i] == "rate" {
-			nodePort = 30003
-		} else if servicesName[i] == "profile" {
-			nodePort = 30002
-		} else if servicesName[i] == "reservation" {
-			nodePort = 30005
-		} else if servicesName[i] == "user" {
-			nodePort = 30006
-		} else if servicesName[i] == "geo" {
-			nodePort = 30001
-		} else {
-			nodePort = 30004
+			return r.reconcileFailed(ctx, instance, statefulSetName, err)
 		}
 
-		service := operator.Service(statefulSetName, 27017, 27017, nodePort)
+		service := operator.Service(instance, statefulSetName, operator.TierData, 27017, 27017)
 		err = bootstrapClient.CreateResource(statefulSetName, service)
 		if err != nil {
 			log.Error(err, "failed to create operator's mongodb Service", "Name", statefulSetName)
-			return ctrl.Result{}, err
+			return r.reconcileFailed(ctx, instance, statefulSetName, err)
+		}
+
+		err = bootstrapClient.CreateResource(operator.HeadlessServiceName(statefulSetName), operator.HeadlessService(instance, statefulSetName))
+		if err != nil {
+			log.Error(err, "failed to create operator's mongodb headless Service", "Name", statefulSetName)
+			return r.reconcileFailed(ctx, instance, statefulSetName, err)
+		}
+
+		err = bootstrapClient.CreateResource(operator.ReplicaSetInitJobName(statefulSetName), operator.ReplicaSetInitJob(instance, statefulSetName))
+		if err != nil {
+			log.Error(err, "failed to create operator's mongodb replica set initiation Job", "Name", statefulSetName)
+			return r.reconcileFailed(ctx, instance, statefulSetName, err)
+		}
+
+		err = bootstrapClient.CreateResource(operator.BackupCronJobName(statefulSetName), operator.BackupCronJob(instance, statefulSetName))
+		if err != nil {
+			log.Error(err, "failed to create operator's mongodb backup CronJob", "Name", statefulSetName)
+			return r.reconcileFailed(ctx, instance, statefulSetName, err)
 		}
 
 	}
 
+	//The volumes of the mongodb instances are expanded in place when their size increases
+	componentErrors, err := r.expandVolumes(ctx, instance)
+	if err != nil {
+		log.Error(err, "failed to expand operator's mongodb PersistentVolumeClaims")
+		return ctrl.Result{}, err
+	}
+	for name, err := range credentialsErrors {
+		componentErrors[name] = err
+	}
+
+	//An app created from a backup has its mongodb instances restored before the logic services start
+	restored, restoreErrors, err := r.reconcileRestore(ctx, instance, bootstrapClient, credentials)
+	if err != nil {
+		log.Error(err, "failed to create operator's mongodb restore Jobs")
+		return r.reconcileFailed(ctx, instance, "restore", err)
+	}
+	for name, err := range restoreErrors {
+		componentErrors[name] = err
+	}
+
 	//Then we create consul service
 	deploymentForConsul := operator.DeploymentForConsul(instance)
 	err = bootstrapClient.CreateResource("consul", deploymentForConsul)
 	if err != nil {
 		log.Error(err, "failed to create operator's consul Deployment", "Name", "consul")
-		return ctrl.Result{}, err
+		return r.reconcileFailed(ctx, instance, "consul", err)
+	}
+
+	err = bootstrapClient.CreateResource("consul", operator.ServiceForConsul(instance))
+	if err != nil {
+		log.Error(err, "failed to create operator's consul Service", "Name", "consul")
+		return r.reconcileFailed(ctx, instance, "consul", err)
 	}
 
 	//Then we create jaeger service
@@ -166,47 +258,156 @@ func (r *HotelReservationAppReconciler) Reconcile(ctx context.Context, req ctrl.
 	err = bootstrapClient.CreateResource("jaeger", deploymentForJaeger)
 	if err != nil {
 		log.Error(err, "failed to create operator's jaeger Deployment", "Name", "jaeger")
+		return r.reconcileFailed(ctx, instance, "jaeger", err)
+	}
+
+	err = bootstrapClient.CreateResource("jaeger", operator.ServiceForJaeger(instance))
+	if err != nil {
+		log.Error(err, "failed to create operator's jaeger Service", "Name", "jaeger")
+		return r.reconcileFailed(ctx, instance, "jaeger", err)
+	}
+
+	//Then we render the configuration of the logic services
+	err = bootstrapClient.CreateResource(operator.ConfigMapName, operator.ConfigMapForServices(instance, credentials))
+	if err != nil {
+		log.Error(err, "failed to create operator's config ConfigMap", "Name", operator.ConfigMapName)
+		return r.reconcileFailed(ctx, instance, operator.ConfigMapName, err)
+	}
+	err = bootstrapClient.CreateResource(operator.ConfigMapName, operator.ConfigSecretForServices(instance, credentials))
+	if err != nil {
+		log.Error(err, "failed to create operator's config Secret", "Name", operator.ConfigMapName)
+		return r.reconcileFailed(ctx, instance, operator.ConfigMapName, err)
+	}
+
+	//The logic services are only rolled out once the infrastructure they rely on is ready
+	infrastructureReady, err := r.workloadsReady(ctx, instance, operator.InfrastructureWorkloads(instance))
+	if err != nil {
+		log.Error(err, "failed to check the readiness of the infrastructure")
 		return ctrl.Result{}, err
 	}
+	if operator.AuthEnabled(instance) {
+		infrastructureReady = infrastructureReady && len(credentials) == len(operator.EnabledDatabases(instance))
+	}
+	infrastructureReady = infrastructureReady && restored
+	if infrastructureReady {
+		infrastructureReady, err = r.replicaSetsInitiated(ctx, instance)
+		if err != nil {
+			log.Error(err, "failed to check the initiation of the mongodb replica sets")
+			return ctrl.Result{}, err
+		}
+	}
+
+	//Then we create the logic services in the order of the catalogue, each once the services it
+	//calls are ready. Services that already exist keep being reconciled so spec changes reach them
+	pending := false
+	for _, component := range components {
+		ready := infrastructureReady
+		if ready {
+			ready, err = r.workloadsReady(ctx, instance, operator.EnabledDependencies(instance, component))
+			if err != nil {
+				log.Error(err, "failed to check the readiness of the dependencies", "Name", component.Name)
+				return ctrl.Result{}, err
+			}
+		}
+		if !ready {
+			exists, err := r.workloadExists(ctx, instance, component.Name)
+			if err != nil {
+				log.Error(err, "failed to get operator's logic Deployment", "Name", component.Name)
+				return ctrl.Result{}, err
+			}
+			if !exists {
+				log.Info("Waiting for dependencies to become ready", "Name", component.Name)
+				pending = true
+				continue
+			}
+		}
 
-	//Then we create logic services,include search geo rate profile recommendation user
+		deploymentForLogic := operator.DeploymentForLogic(component, instance, credentials)
+		err = bootstrapClient.CreateResource(component.Name, deploymentForLogic)
+		if err != nil {
+			log.Error(err, "failed to create operator's logic Deployment", "Name", component.Name)
+			return r.reconcileFailed(ctx, instance, component.Name, err)
+		}
 
-	for i := 0; i < 8; i++ {
+		service := operator.ServiceForLogic(component, instance)
+		err = bootstrapClient.CreateResource(component.Name, service)
+		if err != nil {
+			log.Error(err, "failed to create operator's logic Service", "Name", component.Name)
+			return r.reconcileFailed(ctx, instance, component.Name, err)
+		}
 
-		var port int32 = 0
-		if servicesName[i] == "rate" {
-			port = 8084
-		} else if servicesName[i] == "profile" {
-			port = 8081
-		} else if servicesName[i] == "reservation" {
-			port = 8087
-		} else if servicesName[i] == "user" {
-			port = 8086
-		} else if servicesName[i] == "geo" {
-			port = 8083
-		} else if servicesName[i] == "frontend" {
-			port = 5000
-		} else if servicesName[i] == "search" {
-			port = 8082
-		} else {
-			port = 8085
+		err = bootstrapClient.CreateResource(component.Name, operator.HorizontalPodAutoscaler(component, instance))
+		if err != nil {
+			log.Error(err, "failed to create operator's logic HorizontalPodAutoscaler", "Name", component.Name)
+			return r.reconcileFailed(ctx, instance, component.Name, err)
 		}
+	}
+
+	//Then we expose the frontend outside of the cluster if an Ingress is requested
+	err = bootstrapClient.CreateResource("frontend", operator.IngressForFrontend(instance))
+	if err != nil {
+		log.Error(err, "failed to reconcile operator's frontend Ingress", "Name", "frontend")
+		return r.reconcileFailed(ctx, instance, "frontend", err)
+	}
 
-		deploymentForLogic := operator.DeploymentForLogic(servicesName[i], port, instance)
-		err = bootstrapClient.CreateResource(servicesName[i], deploymentForLogic)
+	//Then we protect the components running several replicas from voluntary disruptions
+	budgets := operator.PodDisruptionBudgets(instance)
+	budgetNames := make([]string, 0, len(budgets))
+	for name := range budgets {
+		budgetNames = append(budgetNames, name)
+	}
+	sort.Strings(budgetNames)
+	for _, name := range budgetNames {
+		err = bootstrapClient.CreateResource(name, budgets[name])
 		if err != nil {
-			log.Error(err, "failed to create operator's logic Deployment", "Name", servicesName[i])
-			return ctrl.Result{}, err
+			log.Error(err, "failed to create operator's PodDisruptionBudget", "Name", name)
+			return r.reconcileFailed(ctx, instance, name, err)
 		}
+	}
+
+	//Then we remove the resources left over from components that are no longer desired, once
+	//every desired resource has been reconciled
+	err = r.pruneStale(ctx, instance, bootstrapClient)
+	if err != nil {
+		log.Error(err, "failed to prune operator's stale resources")
+		return ctrl.Result{}, err
+	}
+
+	//Finally we report the readiness of every component in the status, along with the services
+	//left calling a disabled service and the volumes that cannot be expanded
+	for name, err := range operator.DependencyErrors(instance) {
+		componentErrors[name] = err
+	}
+	err = r.updateStatus(ctx, instance, componentErrors)
+	if err != nil {
+		log.Error(err, "failed to update HotelReservationApp status")
+		return ctrl.Result{}, err
+	}
 
+	//Check again later while a stage of the rollout is still waiting
+	if pending || instance.Status.Phase != examplev1beta1.PhaseRunning {
+		return ctrl.Result{RequeueAfter: rolloutRequeueInterval}, nil
 	}
 
 	return ctrl.Result{}, nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
+// The Deployments, StatefulSets, Services, ConfigMaps, Secrets, Jobs and Ingresses created through the bootstrap client carry a
+// controller reference to the HotelReservationApp, so any change to them (including deletion
+// or a rollout changing their readiness) triggers a reconcile of the owning instance
 func (r *HotelReservationAppReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&examplev1beta1.HotelReservationApp{}).
+		Owns(&appsv1.Deployment{}).
+		Owns(&appsv1.StatefulSet{}).
+		Owns(&corev1.Service{}).
+		Owns(&corev1.ConfigMap{}).
+		Owns(&corev1.Secret{}).
+		Owns(&batchv1.Job{}).
+		Owns(&batchv1.CronJob{}).
+		Owns(&networkingv1.Ingress{}).
+		Owns(&autoscalingv2.HorizontalPodAutoscaler{}).
+		Owns(&policyv1.PodDisruptionBudget{}).
 		Complete(r)
 }
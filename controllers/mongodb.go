@@ -0,0 +1,104 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	examplev1beta1 "github.com/Youngpig1998/hotelreservation-operator/api/v1beta1"
+	"github.com/Youngpig1998/hotelreservation-operator/internal/operator"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// secretValue returns the value of a key of a Secret, empty when the Secret or the key does not exist
+func (r *HotelReservationAppReconciler) secretValue(ctx context.Context, namespace string, name string, key string) (string, error) {
+	secret := &corev1.Secret{}
+	err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, secret)
+	if errors.IsNotFound(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	return string(secret.Data[key]), nil
+}
+
+// keyfile returns the keyfile of the mongodb replica sets of the app, generating a new one when
+// there is none yet. The keyfile is never changed afterwards since every member has to share it.
+// There is no keyfile outside of replica set mode
+func (r *HotelReservationAppReconciler) keyfile(ctx context.Context, app *examplev1beta1.HotelReservationApp) (string, error) {
+	if !operator.ReplicaSetMode(app) {
+		return "", nil
+	}
+	keyfile, err := r.secretValue(ctx, app.Namespace, operator.KeyfileSecretName, operator.KeyfileKey)
+	if err != nil || keyfile != "" {
+		return keyfile, err
+	}
+	return operator.GenerateKeyfile()
+}
+
+// orphanStatefulSetOnServiceNameChange deletes the named StatefulSet, leaving its pods and
+// volumes in place, when it is governed by another Service than serviceName. The governing
+// Service of a StatefulSet cannot be updated, so the StatefulSet is recreated with the new one
+// and adopts the orphaned pods, rolling them onto the new Service
+func (r *HotelReservationAppReconciler) orphanStatefulSetOnServiceNameChange(ctx context.Context, namespace string, name string, serviceName string) error {
+	statefulSet := &appsv1.StatefulSet{}
+	err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, statefulSet)
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if statefulSet.Spec.ServiceName == serviceName || !statefulSet.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	r.Log.Info("Recreating StatefulSet governed by another Service", "Name", name, "From", statefulSet.Spec.ServiceName, "To", serviceName)
+	err = r.Delete(ctx, statefulSet, client.PropagationPolicy("Orphan"))
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// replicaSetsInitiated returns whether the Jobs initiating the mongodb replica sets of the app
+// have all succeeded, it is always true outside of replica set mode
+func (r *HotelReservationAppReconciler) replicaSetsInitiated(ctx context.Context, app *examplev1beta1.HotelReservationApp) (bool, error) {
+	if !operator.ReplicaSetMode(app) {
+		return true, nil
+	}
+	for _, component := range operator.EnabledComponents(app) {
+		if !component.NeedsDB {
+			continue
+		}
+		job := &batchv1.Job{}
+		err := r.Get(ctx, types.NamespacedName{Name: operator.ReplicaSetInitJobName(component.DBName()), Namespace: app.Namespace}, job)
+		if errors.IsNotFound(err) {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+		if job.Status.Succeeded == 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
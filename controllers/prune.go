@@ -0,0 +1,94 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	examplev1beta1 "github.com/Youngpig1998/hotelreservation-operator/api/v1beta1"
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/bootstrap"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// prunable is a kind of resource the operator creates for a HotelReservationApp, named as the
+// bootstrap client records the resources it applies
+type prunable struct {
+	list client.ObjectList
+	kind string
+}
+
+// prunables lists every kind of resource created through the bootstrap client
+func prunables() []prunable {
+	return []prunable{
+		{list: &appsv1.DeploymentList{}, kind: "Deployment"},
+		{list: &appsv1.StatefulSetList{}, kind: "StatefulSet"},
+		{list: &corev1.ServiceList{}, kind: "Service"},
+		{list: &corev1.ConfigMapList{}, kind: "ConfigMap"},
+		{list: &corev1.SecretList{}, kind: "Secret"},
+		{list: &batchv1.JobList{}, kind: "Job"},
+		{list: &batchv1.CronJobList{}, kind: "CronJob"},
+		{list: &networkingv1.IngressList{}, kind: "Ingress"},
+		{list: &autoscalingv2.HorizontalPodAutoscalerList{}, kind: "HorizontalPodAutoscaler"},
+		{list: &policyv1.PodDisruptionBudgetList{}, kind: "PodDisruptionBudget"},
+	}
+}
+
+// appliedResources tells whether a resource has been created or updated during a reconcile,
+// as the bootstrap client does
+type appliedResources interface {
+	Applied(kind string, name string) bool
+}
+
+// pruneStale removes the resources labelled as belonging to the HotelReservationApp and
+// controlled by it that were not applied during this reconcile, such as the resources of a
+// component that has been disabled. It is the only path removing resources: an object of the
+// same name the app does not control is never touched. It must only run once every desired
+// resource has been reconciled
+func (r *HotelReservationAppReconciler) pruneStale(ctx context.Context, app *examplev1beta1.HotelReservationApp, applied appliedResources) error {
+	for _, kind := range prunables() {
+		err := r.List(ctx, kind.list, client.InNamespace(app.Namespace), client.MatchingLabels{bootstrap.InstanceLabel: app.Name})
+		if err != nil {
+			return err
+		}
+		items, err := meta.ExtractList(kind.list)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			object, ok := item.(client.Object)
+			if !ok || !metav1.IsControlledBy(object, app) || applied.Applied(kind.kind, object.GetName()) {
+				continue
+			}
+			r.Log.Info("Pruning resource no longer desired", "Kind", kind.kind, "Name", object.GetName())
+			// The dependents are deleted along with the resource, a Job would otherwise orphan its pods
+			err := r.Delete(ctx, object, client.PropagationPolicy(metav1.DeletePropagationBackground))
+			if err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
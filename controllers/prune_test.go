@@ -0,0 +1,107 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	examplev1beta1 "github.com/Youngpig1998/hotelreservation-operator/api/v1beta1"
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/bootstrap"
+)
+
+// appliedNames records the resources applied during a reconcile as <kind>/<name>
+type appliedNames map[string]bool
+
+func (a appliedNames) Applied(kind string, name string) bool {
+	return a[kind+"/"+name]
+}
+
+// instanceObject labels object as a resource of the hotel app, controlled by the object of the
+// given UID when it is set
+func instanceObject(object client.Object, controller types.UID) client.Object {
+	object.SetNamespace("hotel")
+	object.SetLabels(map[string]string{bootstrap.InstanceLabel: "hotel"})
+	if controller != "" {
+		object.SetOwnerReferences([]metav1.OwnerReference{{
+			APIVersion: examplev1beta1.GroupVersion.String(),
+			Kind:       "HotelReservationApp",
+			Name:       "hotel",
+			UID:        controller,
+			Controller: pointer.BoolPtr(true),
+		}})
+	}
+	return object
+}
+
+func TestPruneStale(t *testing.T) {
+	tests := []struct {
+		name       string
+		object     client.Object
+		applied    appliedNames
+		wantPruned bool
+	}{
+		{"prunes a controlled resource that was not applied",
+			instanceObject(&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "geo"}}, "hotel-uid"), nil, true},
+		{"keeps a controlled resource that was applied",
+			instanceObject(&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "geo"}}, "hotel-uid"), appliedNames{"Deployment/geo": true}, false},
+		{"keeps an unowned object of a colliding name",
+			instanceObject(&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "geo"}}, ""), nil, false},
+		{"keeps a resource controlled by another object",
+			instanceObject(&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "geo"}}, "other-uid"), nil, false},
+		{"only counts a resource applied under its own kind",
+			instanceObject(&appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "memcached-rate"}}, "hotel-uid"),
+			appliedNames{"Deployment/memcached-rate": true}, true},
+		{"prunes the frontend Ingress once no longer requested",
+			instanceObject(&networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "frontend"}}, "hotel-uid"), nil, true},
+		{"keeps an unowned Ingress named frontend",
+			instanceObject(&networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "frontend"}}, ""), nil, false},
+		{"prunes the HorizontalPodAutoscaler of a service no longer autoscaled",
+			instanceObject(&autoscalingv2.HorizontalPodAutoscaler{ObjectMeta: metav1.ObjectMeta{Name: "search"}}, "hotel-uid"), nil, true},
+		{"keeps an unowned HorizontalPodAutoscaler named after a service",
+			instanceObject(&autoscalingv2.HorizontalPodAutoscaler{ObjectMeta: metav1.ObjectMeta{Name: "search"}}, ""), nil, false},
+		{"prunes the PodDisruptionBudget of a component scaled down to a single replica",
+			instanceObject(&policyv1.PodDisruptionBudget{ObjectMeta: metav1.ObjectMeta{Name: "frontend"}}, "hotel-uid"), nil, true},
+		{"keeps an unowned PodDisruptionBudget named after a component",
+			instanceObject(&policyv1.PodDisruptionBudget{ObjectMeta: metav1.ObjectMeta{Name: "frontend"}}, ""), nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := testApp(nil)
+			app.UID = "hotel-uid"
+			r := newTestReconciler(t, tt.object)
+
+			if err := r.pruneStale(context.Background(), app, tt.applied); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			err := r.Get(context.Background(), client.ObjectKeyFromObject(tt.object), tt.object)
+			if pruned := errors.IsNotFound(err); pruned != tt.wantPruned {
+				t.Errorf("got pruned %v (%v), want %v", pruned, err, tt.wantPruned)
+			}
+		})
+	}
+}
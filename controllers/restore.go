@@ -0,0 +1,84 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	examplev1beta1 "github.com/Youngpig1998/hotelreservation-operator/api/v1beta1"
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/bootstrap"
+	"github.com/Youngpig1998/hotelreservation-operator/internal/operator"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// reconcileRestore restores the mongodb instances of an app created from a backup with a Job
+// per instance, and records the outcome in the Restored condition. The restore only ever runs
+// once: when the condition is true the Jobs are no longer applied, so they get pruned and a
+// database is never overwritten by a Job recreated later. With authentication enabled an
+// instance is only restored once its users exist. It returns whether every instance has been
+// restored, along with the errors of the instances whose Job failed
+func (r *HotelReservationAppReconciler) reconcileRestore(ctx context.Context, app *examplev1beta1.HotelReservationApp, bootstrapClient *bootstrap.Client, credentials operator.MongoCredentials) (bool, map[string]error, error) {
+	restoreErrors := map[string]error{}
+	if app.Spec.Restore == nil || meta.IsStatusConditionTrue(app.Status.Conditions, examplev1beta1.ConditionRestored) {
+		return true, restoreErrors, nil
+	}
+
+	var pending []string
+	for _, name := range operator.EnabledDatabases(app) {
+		if _, ok := credentials[name]; operator.AuthEnabled(app) && !ok {
+			pending = append(pending, name)
+			continue
+		}
+		err := bootstrapClient.CreateResource(operator.RestoreJobName(name), operator.RestoreJob(app, name))
+		if err != nil {
+			return false, nil, err
+		}
+		succeeded, err := r.jobSucceeded(ctx, app.Namespace, operator.RestoreJobName(name))
+		if err != nil {
+			restoreErrors[name] = err
+		}
+		if !succeeded {
+			pending = append(pending, name)
+		}
+	}
+	sort.Strings(pending)
+
+	restored := metav1.Condition{
+		Type:               examplev1beta1.ConditionRestored,
+		Status:             metav1.ConditionTrue,
+		Reason:             "BackupRestored",
+		Message:            fmt.Sprintf("Restored backup %s", app.Spec.Restore.Backup),
+		ObservedGeneration: app.Generation,
+	}
+	if len(restoreErrors) != 0 {
+		restored.Status = metav1.ConditionFalse
+		restored.Reason = "RestoreFailed"
+		restored.Message = fmt.Sprintf("Failed to restore backup %s", app.Spec.Restore.Backup)
+	} else if len(pending) != 0 {
+		restored.Status = metav1.ConditionFalse
+		restored.Reason = "Restoring"
+		restored.Message = fmt.Sprintf("Restoring backup %s: %s", app.Spec.Restore.Backup, strings.Join(pending, ", "))
+	} else {
+		r.Log.Info("Backup restored", "Backup", app.Spec.Restore.Backup)
+	}
+	meta.SetStatusCondition(&app.Status.Conditions, restored)
+	return len(pending) == 0, restoreErrors, nil
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	examplev1beta1 "github.com/Youngpig1998/hotelreservation-operator/api/v1beta1"
+	"github.com/Youngpig1998/hotelreservation-operator/internal/operator"
+)
+
+// rolloutRequeueInterval is how long to wait before checking again on a stage of the rollout
+// that is not ready yet
+var rolloutRequeueInterval = 10 * time.Second
+
+// workloadsReady returns whether the workloads of the named components all exist and have
+// all of their desired replicas ready
+func (r *HotelReservationAppReconciler) workloadsReady(ctx context.Context, app *examplev1beta1.HotelReservationApp, names []string) (bool, error) {
+	workloads := operator.Workloads(app)
+	for _, name := range names {
+		status, found, err := r.componentStatus(ctx, app, name, workloads[name])
+		if err != nil {
+			return false, err
+		}
+		if !found || status.ReadyReplicas < status.DesiredReplicas {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// workloadExists returns whether the workload of the named component has already been created
+func (r *HotelReservationAppReconciler) workloadExists(ctx context.Context, app *examplev1beta1.HotelReservationApp, name string) (bool, error) {
+	_, found, err := r.componentStatus(ctx, app, name, operator.Workloads(app)[name])
+	return found, err
+}
+
+// rolloutPhase returns the stage the rollout has reached given the components that are not ready
+func rolloutPhase(app *examplev1beta1.HotelReservationApp, notReady []string) string {
+	pending := map[string]struct{}{}
+	for _, name := range notReady {
+		pending[name] = struct{}{}
+	}
+	anyPending := func(names []string) bool {
+		for _, name := range names {
+			if _, ok := pending[name]; ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	var backend, frontend []string
+	for _, component := range operator.EnabledComponents(app) {
+		if component.Tier == operator.TierFrontend {
+			frontend = append(frontend, component.Name)
+		} else {
+			backend = append(backend, component.Name)
+		}
+	}
+
+	switch {
+	case anyPending(operator.InfrastructureWorkloads(app)):
+		return examplev1beta1.PhaseInfrastructure
+	case anyPending(backend):
+		return examplev1beta1.PhaseBackend
+	case anyPending(frontend):
+		return examplev1beta1.PhaseFrontend
+	}
+	return examplev1beta1.PhaseRunning
+}
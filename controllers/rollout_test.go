@@ -0,0 +1,105 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	examplev1beta1 "github.com/Youngpig1998/hotelreservation-operator/api/v1beta1"
+)
+
+// testApp returns a HotelReservationApp with the given component overrides
+func testApp(components map[string]examplev1beta1.ComponentSpec) *examplev1beta1.HotelReservationApp {
+	return &examplev1beta1.HotelReservationApp{
+		ObjectMeta: metav1.ObjectMeta{Name: "hotel", Namespace: "hotel"},
+		Spec:       examplev1beta1.HotelReservationAppSpec{Components: components},
+	}
+}
+
+func TestRolloutPhase(t *testing.T) {
+	disabled := examplev1beta1.ComponentSpec{Enabled: pointer.BoolPtr(false)}
+	tests := []struct {
+		name       string
+		components map[string]examplev1beta1.ComponentSpec
+		notReady   []string
+		want       string
+	}{
+		{"running when everything is ready", nil, nil, examplev1beta1.PhaseRunning},
+		{"waits on consul", nil, []string{"consul", "geo"}, examplev1beta1.PhaseInfrastructure},
+		{"waits on a mongodb instance", nil, []string{"mongodb-geo", "frontend"}, examplev1beta1.PhaseInfrastructure},
+		{"waits on a backend service", nil, []string{"search", "frontend"}, examplev1beta1.PhaseBackend},
+		{"waits on the frontend", nil, []string{"frontend"}, examplev1beta1.PhaseFrontend},
+		{"ignores the instances of disabled services", map[string]examplev1beta1.ComponentSpec{"geo": disabled},
+			[]string{"mongodb-geo"}, examplev1beta1.PhaseRunning},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rolloutPhase(testApp(tt.components), tt.notReady); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkloadsReady(t *testing.T) {
+	objects := []client.Object{
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "consul", Namespace: "hotel"},
+			Status:     appsv1.DeploymentStatus{ReadyReplicas: 1},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "jaeger", Namespace: "hotel"},
+		},
+		&appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "mongodb-geo", Namespace: "hotel"},
+			Status:     appsv1.StatefulSetStatus{ReadyReplicas: 1},
+		},
+	}
+	r := &HotelReservationAppReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(objects...).Build(),
+	}
+
+	tests := []struct {
+		name  string
+		names []string
+		want  bool
+	}{
+		{"nothing to wait on", nil, true},
+		{"ready deployment and statefulset", []string{"consul", "mongodb-geo"}, true},
+		{"deployment without ready replicas", []string{"consul", "jaeger"}, false},
+		{"missing workload", []string{"mongodb-geo", "mongodb-user"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ready, err := r.workloadsReady(context.Background(), testApp(nil), tt.names)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ready != tt.want {
+				t.Errorf("got %t, want %t", ready, tt.want)
+			}
+		})
+	}
+}
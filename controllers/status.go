@@ -0,0 +1,188 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	examplev1beta1 "github.com/Youngpig1998/hotelreservation-operator/api/v1beta1"
+	"github.com/Youngpig1998/hotelreservation-operator/internal/operator"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// reconcileFailed records the error met while reconciling the named component in the status
+// of the HotelReservationApp and returns it so that the request is retried
+func (r *HotelReservationAppReconciler) reconcileFailed(ctx context.Context, app *examplev1beta1.HotelReservationApp, component string, reconcileErr error) (ctrl.Result, error) {
+	if err := r.updateStatus(ctx, app, map[string]error{component: reconcileErr}); err != nil {
+		r.Log.Error(err, "failed to update status", "hotelreservation", types.NamespacedName{Name: app.Name, Namespace: app.Namespace})
+	}
+	return ctrl.Result{}, reconcileErr
+}
+
+// updateStatus recomputes the per-component readiness and the standard conditions of the
+// HotelReservationApp from the Deployments and StatefulSets it owns, then writes them to the
+// status subresource. componentErrors holds the errors met while reconciling components
+func (r *HotelReservationAppReconciler) updateStatus(ctx context.Context, app *examplev1beta1.HotelReservationApp, componentErrors map[string]error) error {
+	statuses := map[string]examplev1beta1.ComponentStatus{}
+	var notReady, failed []string
+	for name, kind := range operator.Workloads(app) {
+		status, found, err := r.componentStatus(ctx, app, name, kind)
+		if err != nil {
+			return err
+		}
+		if componentErr, ok := componentErrors[name]; ok && componentErr != nil {
+			status.LastError = componentErr.Error()
+		}
+		statuses[name] = status
+
+		if !found || status.ReadyReplicas < status.DesiredReplicas {
+			notReady = append(notReady, name)
+		}
+		if status.LastError != "" {
+			failed = append(failed, name)
+		}
+	}
+	sort.Strings(notReady)
+	sort.Strings(failed)
+
+	var reconcileErrs []string
+	for name, err := range componentErrors {
+		if err != nil {
+			reconcileErrs = append(reconcileErrs, fmt.Sprintf("%s: %s", name, err))
+		}
+	}
+	sort.Strings(reconcileErrs)
+
+	generation := app.Generation
+	conditions := []metav1.Condition{
+		newCondition(examplev1beta1.ConditionAvailable, len(notReady) == 0, "AllComponentsReady", "ComponentsNotReady",
+			"Components not ready", notReady, generation),
+		newCondition(examplev1beta1.ConditionProgressing, len(notReady) != 0 && len(failed) == 0, "RollingOut", "NotProgressing",
+			"Waiting for components to become ready", notReady, generation),
+		newCondition(examplev1beta1.ConditionDegraded, len(failed) != 0, "ComponentsFailing", "AsExpected",
+			"Components reporting errors", failed, generation),
+	}
+	reconciled := metav1.Condition{
+		Type:               examplev1beta1.ConditionReconciled,
+		Status:             metav1.ConditionTrue,
+		Reason:             "ReconcileSucceeded",
+		Message:            "All resources have been reconciled",
+		ObservedGeneration: generation,
+	}
+	if len(reconcileErrs) != 0 {
+		reconciled.Status = metav1.ConditionFalse
+		reconciled.Reason = "ReconcileFailed"
+		reconciled.Message = strings.Join(reconcileErrs, "; ")
+	}
+	conditions = append(conditions, reconciled)
+
+	for _, condition := range conditions {
+		meta.SetStatusCondition(&app.Status.Conditions, condition)
+	}
+	app.Status.Components = statuses
+	app.Status.Phase = rolloutPhase(app, notReady)
+	app.Status.ObservedGeneration = generation
+
+	return r.Status().Update(ctx, app)
+}
+
+// componentStatus reads the workload backing a component and reports its replica counts along
+// with any failure surfaced by its conditions. found is false when the workload does not exist yet.
+// The volumes of a StatefulSet are only reported when it is controlled by the app
+func (r *HotelReservationAppReconciler) componentStatus(ctx context.Context, app *examplev1beta1.HotelReservationApp, name string, kind string) (status examplev1beta1.ComponentStatus, found bool, err error) {
+	status = examplev1beta1.ComponentStatus{Kind: kind}
+	namespace := app.Namespace
+	namespacedName := types.NamespacedName{Name: name, Namespace: namespace}
+
+	switch kind {
+	case operator.KindStatefulSet:
+		statefulSet := &appsv1.StatefulSet{}
+		if err := r.Get(ctx, namespacedName, statefulSet); err != nil {
+			if errors.IsNotFound(err) {
+				return status, false, nil
+			}
+			return status, false, err
+		}
+		status.DesiredReplicas = replicasOrDefault(statefulSet.Spec.Replicas)
+		status.ReadyReplicas = statefulSet.Status.ReadyReplicas
+		if metav1.IsControlledBy(statefulSet, app) {
+			status.Volumes, err = r.volumeStatuses(ctx, statefulSet)
+			if err != nil {
+				return status, false, err
+			}
+		}
+		status.LastBackupTime, err = r.lastBackupTime(ctx, namespace, name)
+		if err != nil {
+			return status, false, err
+		}
+	default:
+		deployment := &appsv1.Deployment{}
+		if err := r.Get(ctx, namespacedName, deployment); err != nil {
+			if errors.IsNotFound(err) {
+				return status, false, nil
+			}
+			return status, false, err
+		}
+		status.DesiredReplicas = replicasOrDefault(deployment.Spec.Replicas)
+		status.ReadyReplicas = deployment.Status.ReadyReplicas
+		for _, condition := range deployment.Status.Conditions {
+			failedReplicas := condition.Type == appsv1.DeploymentReplicaFailure && condition.Status == corev1.ConditionTrue
+			stalled := condition.Type == appsv1.DeploymentProgressing && condition.Status == corev1.ConditionFalse
+			if failedReplicas || stalled {
+				status.LastError = condition.Message
+			}
+		}
+	}
+	return status, true, nil
+}
+
+// newCondition builds a condition of the given status, listing the affected components in its
+// message when there are any
+func newCondition(conditionType string, status bool, trueReason string, falseReason string, message string, affected []string, generation int64) metav1.Condition {
+	condition := metav1.Condition{
+		Type:               conditionType,
+		Status:             metav1.ConditionFalse,
+		Reason:             falseReason,
+		Message:            "No components affected",
+		ObservedGeneration: generation,
+	}
+	if status {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = trueReason
+	}
+	if len(affected) != 0 {
+		condition.Message = fmt.Sprintf("%s: %s", message, strings.Join(affected, ", "))
+	}
+	return condition
+}
+
+// replicasOrDefault returns the replica count of a workload spec, which Kubernetes defaults to one
+func replicasOrDefault(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+	return *replicas
+}
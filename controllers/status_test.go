@@ -0,0 +1,123 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	examplev1beta1 "github.com/Youngpig1998/hotelreservation-operator/api/v1beta1"
+)
+
+func TestNewCondition(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      bool
+		affected    []string
+		wantStatus  metav1.ConditionStatus
+		wantReason  string
+		wantMessage string
+	}{
+		{"true without affected components", true, nil, metav1.ConditionTrue, "Yes", "No components affected"},
+		{"false without affected components", false, nil, metav1.ConditionFalse, "No", "No components affected"},
+		{"lists the affected components", false, []string{"geo", "rate"}, metav1.ConditionFalse, "No", "Components not ready: geo, rate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			condition := newCondition(examplev1beta1.ConditionAvailable, tt.status, "Yes", "No", "Components not ready", tt.affected, 3)
+			if condition.Type != examplev1beta1.ConditionAvailable || condition.ObservedGeneration != 3 {
+				t.Errorf("unexpected type or generation: %+v", condition)
+			}
+			if condition.Status != tt.wantStatus || condition.Reason != tt.wantReason || condition.Message != tt.wantMessage {
+				t.Errorf("got %s/%s/%q, want %s/%s/%q", condition.Status, condition.Reason, condition.Message,
+					tt.wantStatus, tt.wantReason, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestComponentStatus(t *testing.T) {
+	replicas := int32(2)
+	objects := []client.Object{
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "geo", Namespace: "hotel"},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+			Status:     appsv1.DeploymentStatus{ReadyReplicas: 2},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "rate", Namespace: "hotel"},
+			Status: appsv1.DeploymentStatus{Conditions: []appsv1.DeploymentCondition{{
+				Type:    appsv1.DeploymentReplicaFailure,
+				Status:  corev1.ConditionTrue,
+				Message: "quota exceeded",
+			}}},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "search", Namespace: "hotel"},
+			Status: appsv1.DeploymentStatus{Conditions: []appsv1.DeploymentCondition{{
+				Type:    appsv1.DeploymentProgressing,
+				Status:  corev1.ConditionFalse,
+				Message: "progress deadline exceeded",
+			}}},
+		},
+		&appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "mongodb-geo", Namespace: "hotel"},
+			Status:     appsv1.StatefulSetStatus{ReadyReplicas: 1},
+		},
+	}
+	r := &HotelReservationAppReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(objects...).Build(),
+	}
+
+	tests := []struct {
+		name      string
+		component string
+		kind      string
+		want      examplev1beta1.ComponentStatus
+		wantFound bool
+	}{
+		{"ready deployment", "geo", "Deployment",
+			examplev1beta1.ComponentStatus{Kind: "Deployment", DesiredReplicas: 2, ReadyReplicas: 2}, true},
+		{"deployment with failed replicas", "rate", "Deployment",
+			examplev1beta1.ComponentStatus{Kind: "Deployment", DesiredReplicas: 1, LastError: "quota exceeded"}, true},
+		{"stalled deployment", "search", "Deployment",
+			examplev1beta1.ComponentStatus{Kind: "Deployment", DesiredReplicas: 1, LastError: "progress deadline exceeded"}, true},
+		{"statefulset defaults to one replica", "mongodb-geo", "StatefulSet",
+			examplev1beta1.ComponentStatus{Kind: "StatefulSet", DesiredReplicas: 1, ReadyReplicas: 1}, true},
+		{"missing workload", "profile", "Deployment",
+			examplev1beta1.ComponentStatus{Kind: "Deployment"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, found, err := r.componentStatus(context.Background(), testApp(nil), tt.component, tt.kind)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if found != tt.wantFound || !reflect.DeepEqual(status, tt.want) {
+				t.Errorf("got %+v (found %t), want %+v (found %t)", status, found, tt.want, tt.wantFound)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	examplev1beta1 "github.com/Youngpig1998/hotelreservation-operator/api/v1beta1"
+	"github.com/Youngpig1998/hotelreservation-operator/internal/operator"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// expandVolumes grows the PersistentVolumeClaims of the mongodb instances whose size in the spec
+// increased. The volume claim templates of a StatefulSet cannot change, so the existing claims
+// are patched directly, which their StorageClass must allow. The returned errors are keyed by
+// mongodb instance and report the claims that cannot be resized
+func (r *HotelReservationAppReconciler) expandVolumes(ctx context.Context, app *examplev1beta1.HotelReservationApp) (map[string]error, error) {
+	expansionErrors := map[string]error{}
+	for _, component := range operator.EnabledComponents(app) {
+		if !component.NeedsDB {
+			continue
+		}
+		size := operator.VolumeSize(app, component.DBName())
+		if size == nil {
+			continue
+		}
+
+		statefulSet, err := r.ownedStatefulSet(ctx, app, component.DBName())
+		if err != nil {
+			return nil, err
+		}
+		if statefulSet == nil {
+			continue
+		}
+		claims, err := r.claimsOf(ctx, statefulSet)
+		if err != nil {
+			return nil, err
+		}
+		for i := range claims {
+			claim := &claims[i]
+			// Only bound claims can be resized, a pending claim is resized once bound
+			if claim.Status.Phase != corev1.ClaimBound {
+				continue
+			}
+			requested := claim.Spec.Resources.Requests[corev1.ResourceStorage]
+			switch requested.Cmp(*size) {
+			case 0:
+				continue
+			case 1:
+				expansionErrors[component.DBName()] = fmt.Errorf("cannot shrink PersistentVolumeClaim %s from %s to %s", claim.Name, requested.String(), size.String())
+				continue
+			}
+
+			expandable, err := r.allowsExpansion(ctx, claim)
+			if err != nil {
+				return nil, err
+			}
+			if !expandable {
+				expansionErrors[component.DBName()] = fmt.Errorf("the StorageClass of PersistentVolumeClaim %s does not allow volume expansion", claim.Name)
+				continue
+			}
+
+			r.Log.Info("Expanding PersistentVolumeClaim", "Name", claim.Name, "From", requested.String(), "To", size.String())
+			patch := client.MergeFrom(claim.DeepCopy())
+			claim.Spec.Resources.Requests[corev1.ResourceStorage] = *size
+			if err := r.Patch(ctx, claim, patch); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return expansionErrors, nil
+}
+
+// allowsExpansion returns whether the StorageClass of a PersistentVolumeClaim allows its volume
+// to be expanded
+func (r *HotelReservationAppReconciler) allowsExpansion(ctx context.Context, claim *corev1.PersistentVolumeClaim) (bool, error) {
+	if claim.Spec.StorageClassName == nil || *claim.Spec.StorageClassName == "" {
+		return false, nil
+	}
+	storageClass := &storagev1.StorageClass{}
+	err := r.Get(ctx, types.NamespacedName{Name: *claim.Spec.StorageClassName}, storageClass)
+	if errors.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return storageClass.AllowVolumeExpansion != nil && *storageClass.AllowVolumeExpansion, nil
+}
+
+// ownedStatefulSet returns the named StatefulSet when it is controlled by the app, and nil when
+// it does not exist or belongs to someone else
+func (r *HotelReservationAppReconciler) ownedStatefulSet(ctx context.Context, app *examplev1beta1.HotelReservationApp, name string) (*appsv1.StatefulSet, error) {
+	statefulSet := &appsv1.StatefulSet{}
+	err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: app.Namespace}, statefulSet)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if !metav1.IsControlledBy(statefulSet, app) {
+		return nil, nil
+	}
+	return statefulSet, nil
+}
+
+// claimsOf lists the PersistentVolumeClaims created from the volume claim templates of a
+// StatefulSet, which carry its selector labels and are named <template>-<StatefulSet>-<ordinal>.
+// A claim that only shares the labels, such as one of another app, is left out
+func (r *HotelReservationAppReconciler) claimsOf(ctx context.Context, statefulSet *appsv1.StatefulSet) ([]corev1.PersistentVolumeClaim, error) {
+	claims := &corev1.PersistentVolumeClaimList{}
+	err := r.List(ctx, claims, client.InNamespace(statefulSet.Namespace), client.MatchingLabels{"io.kompose.service": statefulSet.Name})
+	if err != nil {
+		return nil, err
+	}
+
+	created := []corev1.PersistentVolumeClaim{}
+	for _, claim := range claims.Items {
+		for _, template := range statefulSet.Spec.VolumeClaimTemplates {
+			ordinal := strings.TrimPrefix(claim.Name, template.Name+"-"+statefulSet.Name+"-")
+			if _, err := strconv.Atoi(ordinal); err == nil && ordinal != claim.Name {
+				created = append(created, claim)
+				break
+			}
+		}
+	}
+	return created, nil
+}
+
+// volumeStatuses reports the requested size, the capacity and the expansion state of each
+// PersistentVolumeClaim of a StatefulSet. A claim that is not bound yet has no capacity and is
+// reported as pending rather than expanding
+func (r *HotelReservationAppReconciler) volumeStatuses(ctx context.Context, statefulSet *appsv1.StatefulSet) ([]examplev1beta1.VolumeStatus, error) {
+	claims, err := r.claimsOf(ctx, statefulSet)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []examplev1beta1.VolumeStatus{}
+	for _, claim := range claims {
+		requested := claim.Spec.Resources.Requests[corev1.ResourceStorage]
+		capacity := claim.Status.Capacity[corev1.ResourceStorage]
+		status := examplev1beta1.VolumeStatus{
+			ClaimName: claim.Name,
+			Requested: requested.String(),
+			Capacity:  capacity.String(),
+			State:     examplev1beta1.VolumeStateReady,
+		}
+		if claim.Status.Phase != corev1.ClaimBound {
+			status.State = examplev1beta1.VolumeStatePending
+			statuses = append(statuses, status)
+			continue
+		}
+		if capacity.Cmp(requested) < 0 {
+			status.State = examplev1beta1.VolumeStateExpanding
+		}
+		for _, condition := range claim.Status.Conditions {
+			if condition.Type == corev1.PersistentVolumeClaimFileSystemResizePending && condition.Status == corev1.ConditionTrue {
+				status.State = examplev1beta1.VolumeStateFileSystemResizePending
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	if len(statuses) == 0 {
+		return nil, nil
+	}
+	return statuses, nil
+}
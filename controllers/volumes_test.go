@@ -0,0 +1,165 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	examplev1beta1 "github.com/Youngpig1998/hotelreservation-operator/api/v1beta1"
+)
+
+// sizedClaim returns a bound claim of the mongodb-geo instance requesting size from storageClass
+func sizedClaim(size string, storageClass string) *corev1.PersistentVolumeClaim {
+	claim := claim("geo-mongodb-geo-0", "mongodb-geo")
+	claim.Spec.StorageClassName = pointer.StringPtr(storageClass)
+	claim.Spec.Resources.Requests = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(size)}
+	claim.Status.Phase = corev1.ClaimBound
+	return claim
+}
+
+// pendingClaim returns a claim of the mongodb-geo instance requesting size that is not bound yet
+func pendingClaim(size string) *corev1.PersistentVolumeClaim {
+	claim := sizedClaim(size, "expandable")
+	claim.Status.Phase = corev1.ClaimPending
+	return claim
+}
+
+func TestExpandVolumes(t *testing.T) {
+	storageClasses := []client.Object{
+		&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "expandable"}, AllowVolumeExpansion: pointer.BoolPtr(true)},
+		&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "fixed"}},
+	}
+	tests := []struct {
+		name       string
+		size       string
+		claim      *corev1.PersistentVolumeClaim
+		controller types.UID
+		wantSize   string
+		wantErr    bool
+	}{
+		{"leaves a claim of the requested size", "1Gi", sizedClaim("1Gi", "expandable"), "hotel-uid", "1Gi", false},
+		{"expands a claim of an expandable class", "5Gi", sizedClaim("1Gi", "expandable"), "hotel-uid", "5Gi", false},
+		{"reports a claim of a class without expansion", "5Gi", sizedClaim("1Gi", "fixed"), "hotel-uid", "1Gi", true},
+		{"reports a claim of a missing class", "5Gi", sizedClaim("1Gi", "missing"), "hotel-uid", "1Gi", true},
+		{"reports a shrink", "1Gi", sizedClaim("5Gi", "expandable"), "hotel-uid", "5Gi", true},
+		{"leaves a claim that is not bound yet", "5Gi", pendingClaim("1Gi"), "hotel-uid", "1Gi", false},
+		{"leaves the claim of another app's StatefulSet", "5Gi", sizedClaim("1Gi", "expandable"), "other-uid", "1Gi", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := testApp(nil)
+			app.UID = "hotel-uid"
+			size := resource.MustParse(tt.size)
+			app.Spec.Storage = &examplev1beta1.StorageSpec{Size: &size}
+			r := newTestReconciler(t, append(storageClasses, databaseStatefulSet(tt.controller), tt.claim)...)
+
+			expansionErrors, err := r.expandVolumes(context.Background(), app)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if _, failed := expansionErrors["mongodb-geo"]; failed != tt.wantErr || len(expansionErrors) > 1 {
+				t.Errorf("got expansion errors %v", expansionErrors)
+			}
+			claim := &corev1.PersistentVolumeClaim{}
+			if err := r.Get(context.Background(), types.NamespacedName{Name: tt.claim.Name, Namespace: "hotel"}, claim); err != nil {
+				t.Fatal(err)
+			}
+			if requested := claim.Spec.Resources.Requests[corev1.ResourceStorage]; requested.Cmp(resource.MustParse(tt.wantSize)) != 0 {
+				t.Errorf("got request %s, want %s", requested.String(), tt.wantSize)
+			}
+		})
+	}
+}
+
+func TestVolumeStatuses(t *testing.T) {
+	expanding := sizedClaim("5Gi", "expandable")
+	expanding.Status.Capacity = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")}
+	resizePending := sizedClaim("5Gi", "expandable")
+	resizePending.Status.Capacity = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("5Gi")}
+	resizePending.Status.Conditions = []corev1.PersistentVolumeClaimCondition{{
+		Type:   corev1.PersistentVolumeClaimFileSystemResizePending,
+		Status: corev1.ConditionTrue,
+	}}
+	ready := sizedClaim("5Gi", "expandable")
+	ready.Status.Capacity = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("5Gi")}
+
+	tests := []struct {
+		name  string
+		claim *corev1.PersistentVolumeClaim
+		want  string
+	}{
+		{"ready", ready, examplev1beta1.VolumeStateReady},
+		{"expanding", expanding, examplev1beta1.VolumeStateExpanding},
+		{"file system resize pending", resizePending, examplev1beta1.VolumeStateFileSystemResizePending},
+		{"pending", pendingClaim("5Gi"), examplev1beta1.VolumeStatePending},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestReconciler(t, tt.claim)
+			statuses, err := r.volumeStatuses(context.Background(), databaseStatefulSet("hotel-uid"))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			capacity := tt.claim.Status.Capacity[corev1.ResourceStorage]
+			want := []examplev1beta1.VolumeStatus{{ClaimName: "geo-mongodb-geo-0", Requested: "5Gi", Capacity: capacity.String(), State: tt.want}}
+			if !reflect.DeepEqual(statuses, want) {
+				t.Errorf("got %+v, want %+v", statuses, want)
+			}
+		})
+	}
+
+	r := newTestReconciler(t)
+	if statuses, err := r.volumeStatuses(context.Background(), databaseStatefulSet("hotel-uid")); err != nil || statuses != nil {
+		t.Errorf("got %+v, %v for an instance without claims", statuses, err)
+	}
+}
+
+func TestComponentStatusVolumes(t *testing.T) {
+	tests := []struct {
+		name       string
+		controller types.UID
+		wantClaims int
+	}{
+		{"reports the volumes of a StatefulSet of the app", "hotel-uid", 1},
+		{"leaves out the volumes of another app's StatefulSet", "other-uid", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := testApp(nil)
+			app.UID = "hotel-uid"
+			r := newTestReconciler(t, databaseStatefulSet(tt.controller), sizedClaim("1Gi", "expandable"))
+
+			status, _, err := r.componentStatus(context.Background(), app, "mongodb-geo", "StatefulSet")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(status.Volumes) != tt.wantClaims {
+				t.Errorf("got volumes %+v, want %d", status.Volumes, tt.wantClaims)
+			}
+		})
+	}
+}
@@ -27,6 +27,10 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// InstanceLabel is set on every resource created through the client to the name of its owner,
+// so that the resources of an owner can be listed
+const InstanceLabel = "example.njtech.edu.cn/instance"
+
 // Client is a Kubernetes bootstrap client for an operator
 type Client struct {
 	DiscoveryClient *discovery.DiscoveryClient
@@ -36,6 +40,8 @@ type Client struct {
 	context         context.Context
 	scheme          *runtime.Scheme
 	namespace       string
+	// applied records the names of the resources created or updated through the client by kind
+	applied map[string]map[string]struct{}
 }
 
 var (
@@ -95,6 +101,7 @@ func NewClient(config *rest.Config, scheme *runtime.Scheme, controllerManagerNam
 		context:         context,
 		scheme:          scheme,
 		namespace:       namespace,
+		applied:         map[string]map[string]struct{}{},
 	}, nil
 }
 
@@ -103,11 +110,25 @@ func NewClient(config *rest.Config, scheme *runtime.Scheme, controllerManagerNam
 func (c Client) CreateResource(name string, resource resources.Reconcileable) error {
 
 	resourceNamespacedName := types.NamespacedName{Name: name, Namespace: c.namespace}
-	if !resource.ResourceIsNil() {
-		resource.SetNamespace(c.namespace)
+	// A nil resource is not desired. An existing object of that name is not deleted here, it may
+	// not belong to the owner: the caller prunes the resources the owner controls but did not apply
+	if resource.ResourceIsNil() {
+		return nil
 	}
-
+	resource.SetNamespace(c.namespace)
 	ctrl.SetControllerReference(c.Owner, resource, c.scheme)
+	labels := resource.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[InstanceLabel] = c.Owner.Name
+	resource.SetLabels(labels)
+
+	kind := resource.ResourceKind()
+	if _, ok := c.applied[kind]; !ok {
+		c.applied[kind] = map[string]struct{}{}
+	}
+	c.applied[kind][name] = struct{}{}
 
 	_, _, err := c.resourceClient.Reconcile(resourceNamespacedName, resource)
 	return err
@@ -132,3 +153,10 @@ func (c Client) CreateResource(name string, resource resources.Reconcileable) er
 //	done := commonServicesClient.InitialiseCommonServices(operandRequestNamespacedName, operandRequest)
 //	return done
 //}
+
+// Applied returns whether a resource of the given kind and name has been created or updated
+// through the client, as opposed to left out or removed
+func (c Client) Applied(kind string, name string) bool {
+	_, ok := c.applied[kind][name]
+	return ok
+}
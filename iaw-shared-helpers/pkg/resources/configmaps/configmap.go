@@ -0,0 +1,64 @@
+// ------------------------------------------------------ {COPYRIGHT-TOP} ---
+// IBM Confidential
+// OCO Source Materials
+// 5900-AEO
+//
+// Copyright IBM Corp. 2021
+//
+// The source code for this program is not published or otherwise
+// divested of its trade secrets, irrespective of what has been
+// deposited with the U.S. Copyright Office.
+// ------------------------------------------------------ {COPYRIGHT-END} ---
+package configmaps
+
+import (
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/resources"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ConfigMap is a wrapper around the corev1.ConfigMap object that meets the
+// Reconcileable interface
+type ConfigMap struct {
+	*corev1.ConfigMap
+}
+
+// From returns a new Reconcileable ConfigMap from a corev1.ConfigMap
+func From(configMap *corev1.ConfigMap) *ConfigMap {
+	return &ConfigMap{ConfigMap: configMap}
+}
+
+// ShouldUpdate returns whether the resource should be updated in Kubernetes and
+// the resource to update with. A ConfigMap marked immutable cannot be made mutable
+// again, so it stays immutable
+func (c ConfigMap) ShouldUpdate(current client.Object) (bool, client.Object) {
+	newConfigMap := current.DeepCopyObject().(*corev1.ConfigMap)
+	resources.MergeMetadata(newConfigMap, c)
+	newConfigMap.Data = c.Data
+	newConfigMap.BinaryData = c.BinaryData
+	if newConfigMap.Immutable == nil || !*newConfigMap.Immutable {
+		newConfigMap.Immutable = c.Immutable
+	}
+	return !equality.Semantic.DeepEqual(newConfigMap, current), newConfigMap
+}
+
+// GetResource retrieves the resource instance
+func (c ConfigMap) GetResource() client.Object {
+	return c.ConfigMap
+}
+
+// ResourceKind retrieves the string kind of the resource
+func (c ConfigMap) ResourceKind() string {
+	return "ConfigMap"
+}
+
+// ResourceIsNil returns whether or not the resource is nil
+func (c ConfigMap) ResourceIsNil() bool {
+	return c.ConfigMap == nil
+}
+
+// NewResourceInstance returns a new instance of the same resource type
+func (c ConfigMap) NewResourceInstance() client.Object {
+	return &corev1.ConfigMap{}
+}
@@ -0,0 +1,72 @@
+// ------------------------------------------------------ {COPYRIGHT-TOP} ---
+// IBM Confidential
+// OCO Source Materials
+// 5900-AEO
+//
+// Copyright IBM Corp. 2021
+//
+// The source code for this program is not published or otherwise
+// divested of its trade secrets, irrespective of what has been
+// deposited with the U.S. Copyright Office.
+// ------------------------------------------------------ {COPYRIGHT-END} ---
+package configmaps_test
+
+import (
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/resources/configmaps"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+var _ = Describe("ConfigMap", func() {
+	var current *corev1.ConfigMap
+
+	BeforeEach(func() {
+		current = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "hotelreservation-config",
+				Namespace: "hotel",
+				Labels:    map[string]string{"app": "hotel"},
+			},
+			Data: map[string]string{"config.json": "{}"},
+		}
+	})
+
+	It("describes its kind", func() {
+		Expect(configmaps.From(nil).ResourceIsNil()).To(BeTrue())
+		Expect(configmaps.From(current).ResourceIsNil()).To(BeFalse())
+		Expect(configmaps.From(current).ResourceKind()).To(Equal("ConfigMap"))
+		Expect(configmaps.From(current).NewResourceInstance()).To(BeAssignableToTypeOf(&corev1.ConfigMap{}))
+	})
+
+	It("does not update an unchanged ConfigMap", func() {
+		update, _ := configmaps.From(current.DeepCopy()).ShouldUpdate(current)
+		Expect(update).To(BeFalse())
+	})
+
+	It("replaces the data and merges the labels", func() {
+		desired := current.DeepCopy()
+		desired.Labels = map[string]string{"tier": "logic"}
+		desired.Data = map[string]string{"config.json": "{\"GeoPort\": \"8083\"}"}
+
+		update, updated := configmaps.From(desired).ShouldUpdate(current)
+		Expect(update).To(BeTrue())
+		Expect(updated.(*corev1.ConfigMap).Data).To(Equal(desired.Data))
+		Expect(updated.(*corev1.ConfigMap).Labels).To(Equal(map[string]string{"app": "hotel", "tier": "logic"}))
+	})
+
+	It("keeps an immutable ConfigMap immutable", func() {
+		current.Immutable = pointer.BoolPtr(true)
+
+		_, updated := configmaps.From(current.DeepCopy()).ShouldUpdate(current)
+		Expect(updated.(*corev1.ConfigMap).Immutable).To(Equal(pointer.BoolPtr(true)))
+
+		desired := current.DeepCopy()
+		desired.Immutable = nil
+		update, updated := configmaps.From(desired).ShouldUpdate(current)
+		Expect(update).To(BeFalse())
+		Expect(updated.(*corev1.ConfigMap).Immutable).To(Equal(pointer.BoolPtr(true)))
+	})
+})
@@ -0,0 +1,75 @@
+// ------------------------------------------------------ {COPYRIGHT-TOP} ---
+// IBM Confidential
+// OCO Source Materials
+// 5900-AEO
+//
+// Copyright IBM Corp. 2021
+//
+// The source code for this program is not published or otherwise
+// divested of its trade secrets, irrespective of what has been
+// deposited with the U.S. Copyright Office.
+// ------------------------------------------------------ {COPYRIGHT-END} ---
+
+package cronjobs
+
+import (
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/resources"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// CronJob is a wrapper around the batchv1.CronJob object that meets the
+// Reconcileable interface
+type CronJob struct {
+	*batchv1.CronJob
+}
+
+// From returns a new Reconcileable CronJob from a batchv1.CronJob
+func From(cronJob *batchv1.CronJob) *CronJob {
+	return &CronJob{CronJob: cronJob}
+}
+
+// ShouldUpdate returns whether the resource should be updated in Kubernetes and
+// the resource to update with. Unlike a Job, the whole spec of a CronJob can be
+// updated and applies to the Jobs it schedules afterwards. The scheduling fields
+// left unset are defaulted by Kube, so the current values are kept for them
+func (c CronJob) ShouldUpdate(current client.Object) (bool, client.Object) {
+	newCronJob := current.DeepCopyObject().(*batchv1.CronJob)
+	resources.MergeMetadata(newCronJob, c)
+	currentSpec := newCronJob.Spec
+	newCronJob.Spec = c.Spec
+	if newCronJob.Spec.ConcurrencyPolicy == "" {
+		newCronJob.Spec.ConcurrencyPolicy = currentSpec.ConcurrencyPolicy
+	}
+	if newCronJob.Spec.Suspend == nil {
+		newCronJob.Spec.Suspend = currentSpec.Suspend
+	}
+	if newCronJob.Spec.SuccessfulJobsHistoryLimit == nil {
+		newCronJob.Spec.SuccessfulJobsHistoryLimit = currentSpec.SuccessfulJobsHistoryLimit
+	}
+	if newCronJob.Spec.FailedJobsHistoryLimit == nil {
+		newCronJob.Spec.FailedJobsHistoryLimit = currentSpec.FailedJobsHistoryLimit
+	}
+	return !equality.Semantic.DeepEqual(newCronJob, current), newCronJob
+}
+
+// GetResource retrieves the resource instance
+func (c CronJob) GetResource() client.Object {
+	return c.CronJob
+}
+
+// ResourceKind retrieves the string kind of the resource
+func (c CronJob) ResourceKind() string {
+	return "CronJob"
+}
+
+// ResourceIsNil returns whether or not the resource is nil
+func (c CronJob) ResourceIsNil() bool {
+	return c.CronJob == nil
+}
+
+// NewResourceInstance returns a new instance of the same resource type
+func (c CronJob) NewResourceInstance() client.Object {
+	return &batchv1.CronJob{}
+}
@@ -0,0 +1,81 @@
+// ------------------------------------------------------ {COPYRIGHT-TOP} ---
+// IBM Confidential
+// OCO Source Materials
+// 5900-AEO
+//
+// Copyright IBM Corp. 2021
+//
+// The source code for this program is not published or otherwise
+// divested of its trade secrets, irrespective of what has been
+// deposited with the U.S. Copyright Office.
+// ------------------------------------------------------ {COPYRIGHT-END} ---
+package cronjobs_test
+
+import (
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/resources/cronjobs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+var _ = Describe("CronJob", func() {
+	var current *batchv1.CronJob
+
+	BeforeEach(func() {
+		current = &batchv1.CronJob{
+			ObjectMeta: metav1.ObjectMeta{Name: "mongodb-geo-backup", Namespace: "hotel"},
+			Spec: batchv1.CronJobSpec{
+				Schedule:                   "0 2 * * *",
+				ConcurrencyPolicy:          batchv1.AllowConcurrent,
+				Suspend:                    pointer.BoolPtr(false),
+				SuccessfulJobsHistoryLimit: pointer.Int32Ptr(3),
+				FailedJobsHistoryLimit:     pointer.Int32Ptr(1),
+			},
+		}
+	})
+
+	It("describes its kind", func() {
+		Expect(cronjobs.From(nil).ResourceIsNil()).To(BeTrue())
+		Expect(cronjobs.From(current).ResourceIsNil()).To(BeFalse())
+		Expect(cronjobs.From(current).ResourceKind()).To(Equal("CronJob"))
+		Expect(cronjobs.From(current).NewResourceInstance()).To(BeAssignableToTypeOf(&batchv1.CronJob{}))
+	})
+
+	It("does not update an unchanged CronJob", func() {
+		update, _ := cronjobs.From(current.DeepCopy()).ShouldUpdate(current)
+		Expect(update).To(BeFalse())
+	})
+
+	It("replaces the schedule", func() {
+		desired := current.DeepCopy()
+		desired.Spec.Schedule = "0 3 * * *"
+
+		update, updated := cronjobs.From(desired).ShouldUpdate(current)
+		Expect(update).To(BeTrue())
+		Expect(updated.(*batchv1.CronJob).Spec.Schedule).To(Equal("0 3 * * *"))
+	})
+
+	It("keeps the defaulted scheduling fields when none are set", func() {
+		desired := &batchv1.CronJob{
+			ObjectMeta: current.ObjectMeta,
+			Spec:       batchv1.CronJobSpec{Schedule: current.Spec.Schedule},
+		}
+
+		update, updated := cronjobs.From(desired).ShouldUpdate(current)
+		Expect(update).To(BeFalse())
+		Expect(updated.(*batchv1.CronJob).Spec).To(Equal(current.Spec))
+	})
+
+	It("replaces the scheduling fields that are set", func() {
+		desired := current.DeepCopy()
+		desired.Spec.ConcurrencyPolicy = batchv1.ForbidConcurrent
+		desired.Spec.Suspend = pointer.BoolPtr(true)
+
+		update, updated := cronjobs.From(desired).ShouldUpdate(current)
+		Expect(update).To(BeTrue())
+		Expect(updated.(*batchv1.CronJob).Spec.ConcurrencyPolicy).To(Equal(batchv1.ForbidConcurrent))
+		Expect(updated.(*batchv1.CronJob).Spec.Suspend).To(Equal(pointer.BoolPtr(true)))
+	})
+})
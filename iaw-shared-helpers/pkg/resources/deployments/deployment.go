@@ -30,14 +30,19 @@ func From(deployment *appsv1.Deployment) *Deployment {
 }
 
 // ShouldUpdate returns whether the resource should be updated in Kubernetes and
-// the resource to update with
+// the resource to update with. A Deployment without replicas keeps its current
+// replica count, which is left to whatever scales it such as a HorizontalPodAutoscaler
 func (d Deployment) ShouldUpdate(current client.Object) (bool, client.Object) {
 	newDeployment := current.DeepCopyObject().(*appsv1.Deployment)
 	resources.MergeMetadata(newDeployment, d)
 	resources.MergeMetadata(&newDeployment.Spec.Template, &d.Spec.Template)
 	mergedTemplate := newDeployment.Spec.Template
+	replicas := newDeployment.Spec.Replicas
 	newDeployment.Spec = d.Spec
 	newDeployment.Spec.Template.ObjectMeta = mergedTemplate.ObjectMeta
+	if newDeployment.Spec.Replicas == nil {
+		newDeployment.Spec.Replicas = replicas
+	}
 	return !equality.Semantic.DeepEqual(newDeployment, current), newDeployment
 }
 
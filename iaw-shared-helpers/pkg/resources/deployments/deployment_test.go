@@ -0,0 +1,64 @@
+// ------------------------------------------------------ {COPYRIGHT-TOP} ---
+// IBM Confidential
+// OCO Source Materials
+// 5900-AEO
+//
+// Copyright IBM Corp. 2021
+//
+// The source code for this program is not published or otherwise
+// divested of its trade secrets, irrespective of what has been
+// deposited with the U.S. Copyright Office.
+// ------------------------------------------------------ {COPYRIGHT-END} ---
+package deployments_test
+
+import (
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/resources/deployments"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+var _ = Describe("Deployment", func() {
+	var current *appsv1.Deployment
+
+	BeforeEach(func() {
+		current = &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "search", Namespace: "hotel"},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: pointer.Int32Ptr(4),
+				Strategy: appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType},
+			},
+		}
+	})
+
+	It("replaces the replicas when the desired ones are set", func() {
+		desired := current.DeepCopy()
+		desired.Spec.Replicas = pointer.Int32Ptr(1)
+
+		update, updated := deployments.From(desired).ShouldUpdate(current)
+		Expect(update).To(BeTrue())
+		Expect(updated.(*appsv1.Deployment).Spec.Replicas).To(Equal(pointer.Int32Ptr(1)))
+	})
+
+	It("keeps the current replicas when the desired ones are not set", func() {
+		desired := current.DeepCopy()
+		desired.Spec.Replicas = nil
+
+		update, updated := deployments.From(desired).ShouldUpdate(current)
+		Expect(update).To(BeFalse())
+		Expect(updated.(*appsv1.Deployment).Spec.Replicas).To(Equal(pointer.Int32Ptr(4)))
+	})
+
+	It("updates the rest of the spec when the replicas are not set", func() {
+		desired := current.DeepCopy()
+		desired.Spec.Replicas = nil
+		desired.Spec.Strategy = appsv1.DeploymentStrategy{Type: appsv1.RollingUpdateDeploymentStrategyType}
+
+		update, updated := deployments.From(desired).ShouldUpdate(current)
+		Expect(update).To(BeTrue())
+		Expect(updated.(*appsv1.Deployment).Spec.Strategy.Type).To(Equal(appsv1.RollingUpdateDeploymentStrategyType))
+		Expect(updated.(*appsv1.Deployment).Spec.Replicas).To(Equal(pointer.Int32Ptr(4)))
+	})
+})
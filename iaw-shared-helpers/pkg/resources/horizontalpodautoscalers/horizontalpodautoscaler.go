@@ -0,0 +1,69 @@
+// ------------------------------------------------------ {COPYRIGHT-TOP} ---
+// IBM Confidential
+// OCO Source Materials
+// 5900-AEO
+//
+// Copyright IBM Corp. 2021
+//
+// The source code for this program is not published or otherwise
+// divested of its trade secrets, irrespective of what has been
+// deposited with the U.S. Copyright Office.
+// ------------------------------------------------------ {COPYRIGHT-END} ---
+
+package horizontalpodautoscalers
+
+import (
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/resources"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// HorizontalPodAutoscaler is a wrapper around the autoscalingv2.HorizontalPodAutoscaler
+// object that meets the Reconcileable interface
+type HorizontalPodAutoscaler struct {
+	*autoscalingv2.HorizontalPodAutoscaler
+}
+
+// From returns a new Reconcileable HorizontalPodAutoscaler from an
+// autoscalingv2.HorizontalPodAutoscaler
+func From(hpa *autoscalingv2.HorizontalPodAutoscaler) *HorizontalPodAutoscaler {
+	return &HorizontalPodAutoscaler{HorizontalPodAutoscaler: hpa}
+}
+
+// ShouldUpdate returns whether the resource should be updated in Kubernetes and
+// the resource to update with. The minimum replicas and the scaling behavior are
+// defaulted by the API server, so the current ones are kept when none are set
+func (h HorizontalPodAutoscaler) ShouldUpdate(current client.Object) (bool, client.Object) {
+	newHPA := current.DeepCopyObject().(*autoscalingv2.HorizontalPodAutoscaler)
+	resources.MergeMetadata(newHPA, h)
+	currentSpec := newHPA.Spec
+	newHPA.Spec = h.Spec
+	if newHPA.Spec.MinReplicas == nil {
+		newHPA.Spec.MinReplicas = currentSpec.MinReplicas
+	}
+	if newHPA.Spec.Behavior == nil {
+		newHPA.Spec.Behavior = currentSpec.Behavior
+	}
+	return !equality.Semantic.DeepEqual(newHPA, current), newHPA
+}
+
+// GetResource retrieves the resource instance
+func (h HorizontalPodAutoscaler) GetResource() client.Object {
+	return h.HorizontalPodAutoscaler
+}
+
+// ResourceKind retrieves the string kind of the resource
+func (h HorizontalPodAutoscaler) ResourceKind() string {
+	return "HorizontalPodAutoscaler"
+}
+
+// ResourceIsNil returns whether or not the resource is nil
+func (h HorizontalPodAutoscaler) ResourceIsNil() bool {
+	return h.HorizontalPodAutoscaler == nil
+}
+
+// NewResourceInstance returns a new instance of the same resource type
+func (h HorizontalPodAutoscaler) NewResourceInstance() client.Object {
+	return &autoscalingv2.HorizontalPodAutoscaler{}
+}
@@ -0,0 +1,72 @@
+// ------------------------------------------------------ {COPYRIGHT-TOP} ---
+// IBM Confidential
+// OCO Source Materials
+// 5900-AEO
+//
+// Copyright IBM Corp. 2021
+//
+// The source code for this program is not published or otherwise
+// divested of its trade secrets, irrespective of what has been
+// deposited with the U.S. Copyright Office.
+// ------------------------------------------------------ {COPYRIGHT-END} ---
+package horizontalpodautoscalers_test
+
+import (
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/resources/horizontalpodautoscalers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+var _ = Describe("HorizontalPodAutoscaler", func() {
+	var current *autoscalingv2.HorizontalPodAutoscaler
+
+	BeforeEach(func() {
+		selectPolicy := autoscalingv2.MaxChangePolicySelect
+		current = &autoscalingv2.HorizontalPodAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Name: "search", Namespace: "hotel"},
+			Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+				ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "search"},
+				MinReplicas:    pointer.Int32Ptr(1),
+				MaxReplicas:    4,
+				Behavior: &autoscalingv2.HorizontalPodAutoscalerBehavior{
+					ScaleUp: &autoscalingv2.HPAScalingRules{SelectPolicy: &selectPolicy},
+				},
+			},
+		}
+	})
+
+	It("describes its kind", func() {
+		Expect(horizontalpodautoscalers.From(nil).ResourceIsNil()).To(BeTrue())
+		Expect(horizontalpodautoscalers.From(current).ResourceIsNil()).To(BeFalse())
+		Expect(horizontalpodautoscalers.From(current).ResourceKind()).To(Equal("HorizontalPodAutoscaler"))
+		Expect(horizontalpodautoscalers.From(current).NewResourceInstance()).To(BeAssignableToTypeOf(&autoscalingv2.HorizontalPodAutoscaler{}))
+	})
+
+	It("does not update an unchanged HorizontalPodAutoscaler", func() {
+		update, _ := horizontalpodautoscalers.From(current.DeepCopy()).ShouldUpdate(current)
+		Expect(update).To(BeFalse())
+	})
+
+	It("replaces the replica limits", func() {
+		desired := current.DeepCopy()
+		desired.Spec.MaxReplicas = 8
+
+		update, updated := horizontalpodautoscalers.From(desired).ShouldUpdate(current)
+		Expect(update).To(BeTrue())
+		Expect(updated.(*autoscalingv2.HorizontalPodAutoscaler).Spec.MaxReplicas).To(Equal(int32(8)))
+	})
+
+	It("keeps the defaulted minimum replicas and behavior when none are set", func() {
+		desired := current.DeepCopy()
+		desired.Spec.MinReplicas = nil
+		desired.Spec.Behavior = nil
+
+		update, updated := horizontalpodautoscalers.From(desired).ShouldUpdate(current)
+		Expect(update).To(BeFalse())
+		Expect(updated.(*autoscalingv2.HorizontalPodAutoscaler).Spec.MinReplicas).To(Equal(pointer.Int32Ptr(1)))
+		Expect(updated.(*autoscalingv2.HorizontalPodAutoscaler).Spec.Behavior).To(Equal(current.Spec.Behavior))
+	})
+})
@@ -0,0 +1,67 @@
+// ------------------------------------------------------ {COPYRIGHT-TOP} ---
+// IBM Confidential
+// OCO Source Materials
+// 5900-AEO
+//
+// Copyright IBM Corp. 2021
+//
+// The source code for this program is not published or otherwise
+// divested of its trade secrets, irrespective of what has been
+// deposited with the U.S. Copyright Office.
+// ------------------------------------------------------ {COPYRIGHT-END} ---
+package ingresses
+
+import (
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/resources"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Ingress is a wrapper around the networkingv1.Ingress object that meets the
+// Reconcileable interface
+type Ingress struct {
+	*networkingv1.Ingress
+}
+
+// From returns a new Reconcileable Ingress from a networkingv1.Ingress
+func From(ingress *networkingv1.Ingress) *Ingress {
+	return &Ingress{Ingress: ingress}
+}
+
+// ShouldUpdate returns whether the resource should be updated in Kubernetes and
+// the resource to update with
+func (i Ingress) ShouldUpdate(current client.Object) (bool, client.Object) {
+	newIngress := current.DeepCopyObject().(*networkingv1.Ingress)
+	resources.MergeMetadata(newIngress, i)
+	newIngress.Spec = i.Spec
+
+	// The ingress class may be defaulted by an admission controller when none is requested,
+	// keep the current one in that case
+	if newIngress.Spec.IngressClassName == nil {
+		currentIngress := current.DeepCopyObject().(*networkingv1.Ingress)
+		newIngress.Spec.IngressClassName = currentIngress.Spec.IngressClassName
+	}
+
+	return !equality.Semantic.DeepEqual(newIngress, current), newIngress
+}
+
+// GetResource retrieves the resource instance
+func (i Ingress) GetResource() client.Object {
+	return i.Ingress
+}
+
+// ResourceKind retrieves the string kind of the resource
+func (i Ingress) ResourceKind() string {
+	return "Ingress"
+}
+
+// ResourceIsNil returns whether or not the resource is nil
+func (i Ingress) ResourceIsNil() bool {
+	return i.Ingress == nil
+}
+
+// NewResourceInstance returns a new instance of the same resource type
+func (i Ingress) NewResourceInstance() client.Object {
+	return &networkingv1.Ingress{}
+}
@@ -0,0 +1,65 @@
+// ------------------------------------------------------ {COPYRIGHT-TOP} ---
+// IBM Confidential
+// OCO Source Materials
+// 5900-AEO
+//
+// Copyright IBM Corp. 2021
+//
+// The source code for this program is not published or otherwise
+// divested of its trade secrets, irrespective of what has been
+// deposited with the U.S. Copyright Office.
+// ------------------------------------------------------ {COPYRIGHT-END} ---
+package ingresses_test
+
+import (
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/resources/ingresses"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+var _ = Describe("Ingress", func() {
+	var current *networkingv1.Ingress
+
+	BeforeEach(func() {
+		current = &networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: "frontend", Namespace: "hotel"},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: pointer.StringPtr("nginx"),
+				Rules:            []networkingv1.IngressRule{{Host: "hotel.example.com"}},
+			},
+		}
+	})
+
+	It("describes its kind", func() {
+		Expect(ingresses.From(nil).ResourceIsNil()).To(BeTrue())
+		Expect(ingresses.From(current).ResourceIsNil()).To(BeFalse())
+		Expect(ingresses.From(current).ResourceKind()).To(Equal("Ingress"))
+		Expect(ingresses.From(current).NewResourceInstance()).To(BeAssignableToTypeOf(&networkingv1.Ingress{}))
+	})
+
+	It("does not update an unchanged Ingress", func() {
+		update, _ := ingresses.From(current.DeepCopy()).ShouldUpdate(current)
+		Expect(update).To(BeFalse())
+	})
+
+	It("replaces the rules", func() {
+		desired := current.DeepCopy()
+		desired.Spec.Rules[0].Host = "reservations.example.com"
+
+		update, updated := ingresses.From(desired).ShouldUpdate(current)
+		Expect(update).To(BeTrue())
+		Expect(updated.(*networkingv1.Ingress).Spec.Rules[0].Host).To(Equal("reservations.example.com"))
+	})
+
+	It("keeps the defaulted ingress class when none is requested", func() {
+		desired := current.DeepCopy()
+		desired.Spec.IngressClassName = nil
+
+		update, updated := ingresses.From(desired).ShouldUpdate(current)
+		Expect(update).To(BeFalse())
+		Expect(updated.(*networkingv1.Ingress).Spec.IngressClassName).To(Equal(pointer.StringPtr("nginx")))
+	})
+})
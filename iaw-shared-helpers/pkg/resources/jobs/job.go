@@ -0,0 +1,61 @@
+// ------------------------------------------------------ {COPYRIGHT-TOP} ---
+// IBM Confidential
+// OCO Source Materials
+// 5900-AEO
+//
+// Copyright IBM Corp. 2021
+//
+// The source code for this program is not published or otherwise
+// divested of its trade secrets, irrespective of what has been
+// deposited with the U.S. Copyright Office.
+// ------------------------------------------------------ {COPYRIGHT-END} ---
+
+package jobs
+
+import (
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/resources"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Job is a wrapper around the batchv1.Job object that meets the
+// Reconcileable interface
+type Job struct {
+	*batchv1.Job
+}
+
+// From returns a new Reconcileable Job from a batchv1.Job
+func From(job *batchv1.Job) *Job {
+	return &Job{Job: job}
+}
+
+// ShouldUpdate returns whether the resource should be updated in Kubernetes and
+// the resource to update with. The pod template of a Job cannot be updated and a Job
+// runs only once, so only its metadata is updated; a Job running a different
+// template has to be given a new name
+func (j Job) ShouldUpdate(current client.Object) (bool, client.Object) {
+	newJob := current.DeepCopyObject().(*batchv1.Job)
+	resources.MergeMetadata(newJob, j)
+	return !equality.Semantic.DeepEqual(newJob, current), newJob
+}
+
+// GetResource retrieves the resource instance
+func (j Job) GetResource() client.Object {
+	return j.Job
+}
+
+// ResourceKind retrieves the string kind of the resource
+func (j Job) ResourceKind() string {
+	return "Job"
+}
+
+// ResourceIsNil returns whether or not the resource is nil
+func (j Job) ResourceIsNil() bool {
+	return j.Job == nil
+}
+
+// NewResourceInstance returns a new instance of the same resource type
+func (j Job) NewResourceInstance() client.Object {
+	return &batchv1.Job{}
+}
@@ -0,0 +1,68 @@
+// ------------------------------------------------------ {COPYRIGHT-TOP} ---
+// IBM Confidential
+// OCO Source Materials
+// 5900-AEO
+//
+// Copyright IBM Corp. 2021
+//
+// The source code for this program is not published or otherwise
+// divested of its trade secrets, irrespective of what has been
+// deposited with the U.S. Copyright Office.
+// ------------------------------------------------------ {COPYRIGHT-END} ---
+package jobs_test
+
+import (
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/resources/jobs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Job", func() {
+	var current *batchv1.Job
+
+	BeforeEach(func() {
+		current = &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: "mongodb-geo-rs-init", Namespace: "hotel"},
+			Spec: batchv1.JobSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "rs-init", Image: "mongo:4.4"}},
+					},
+				},
+			},
+		}
+	})
+
+	It("describes its kind", func() {
+		Expect(jobs.From(nil).ResourceIsNil()).To(BeTrue())
+		Expect(jobs.From(current).ResourceIsNil()).To(BeFalse())
+		Expect(jobs.From(current).ResourceKind()).To(Equal("Job"))
+		Expect(jobs.From(current).NewResourceInstance()).To(BeAssignableToTypeOf(&batchv1.Job{}))
+	})
+
+	It("does not update an unchanged Job", func() {
+		update, _ := jobs.From(current.DeepCopy()).ShouldUpdate(current)
+		Expect(update).To(BeFalse())
+	})
+
+	It("keeps the immutable pod template", func() {
+		desired := current.DeepCopy()
+		desired.Spec.Template.Spec.Containers[0].Image = "mongo:5.0"
+
+		update, updated := jobs.From(desired).ShouldUpdate(current)
+		Expect(update).To(BeFalse())
+		Expect(updated.(*batchv1.Job).Spec.Template.Spec.Containers[0].Image).To(Equal("mongo:4.4"))
+	})
+
+	It("merges the labels", func() {
+		desired := current.DeepCopy()
+		desired.Labels = map[string]string{"io.kompose.service": "mongodb-geo-rs-init"}
+
+		update, updated := jobs.From(desired).ShouldUpdate(current)
+		Expect(update).To(BeTrue())
+		Expect(updated.(*batchv1.Job).Labels).To(Equal(desired.Labels))
+	})
+})
@@ -0,0 +1,65 @@
+// ------------------------------------------------------ {COPYRIGHT-TOP} ---
+// IBM Confidential
+// OCO Source Materials
+// 5900-AEO
+//
+// Copyright IBM Corp. 2021
+//
+// The source code for this program is not published or otherwise
+// divested of its trade secrets, irrespective of what has been
+// deposited with the U.S. Copyright Office.
+// ------------------------------------------------------ {COPYRIGHT-END} ---
+
+package networkpolicies
+
+import (
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/resources"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// NetworkPolicy is a wrapper around the networkingv1.NetworkPolicy object that meets the
+// Reconcileable interface
+type NetworkPolicy struct {
+	*networkingv1.NetworkPolicy
+}
+
+// From returns a new Reconcileable NetworkPolicy from a networkingv1.NetworkPolicy
+func From(networkPolicy *networkingv1.NetworkPolicy) *NetworkPolicy {
+	return &NetworkPolicy{NetworkPolicy: networkPolicy}
+}
+
+// ShouldUpdate returns whether the resource should be updated in Kubernetes and
+// the resource to update with. The policy types are defaulted by Kube from the rules
+// when none are set, so the current ones are kept in that case
+func (n NetworkPolicy) ShouldUpdate(current client.Object) (bool, client.Object) {
+	newNetworkPolicy := current.DeepCopyObject().(*networkingv1.NetworkPolicy)
+	resources.MergeMetadata(newNetworkPolicy, n)
+	policyTypes := newNetworkPolicy.Spec.PolicyTypes
+	newNetworkPolicy.Spec = n.Spec
+	if len(newNetworkPolicy.Spec.PolicyTypes) == 0 {
+		newNetworkPolicy.Spec.PolicyTypes = policyTypes
+	}
+	return !equality.Semantic.DeepEqual(newNetworkPolicy, current), newNetworkPolicy
+}
+
+// GetResource retrieves the resource instance
+func (n NetworkPolicy) GetResource() client.Object {
+	return n.NetworkPolicy
+}
+
+// ResourceKind retrieves the string kind of the resource
+func (n NetworkPolicy) ResourceKind() string {
+	return "NetworkPolicy"
+}
+
+// ResourceIsNil returns whether or not the resource is nil
+func (n NetworkPolicy) ResourceIsNil() bool {
+	return n.NetworkPolicy == nil
+}
+
+// NewResourceInstance returns a new instance of the same resource type
+func (n NetworkPolicy) NewResourceInstance() client.Object {
+	return &networkingv1.NetworkPolicy{}
+}
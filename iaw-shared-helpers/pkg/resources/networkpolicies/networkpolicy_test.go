@@ -0,0 +1,78 @@
+// ------------------------------------------------------ {COPYRIGHT-TOP} ---
+// IBM Confidential
+// OCO Source Materials
+// 5900-AEO
+//
+// Copyright IBM Corp. 2021
+//
+// The source code for this program is not published or otherwise
+// divested of its trade secrets, irrespective of what has been
+// deposited with the U.S. Copyright Office.
+// ------------------------------------------------------ {COPYRIGHT-END} ---
+package networkpolicies_test
+
+import (
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/resources/networkpolicies"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("NetworkPolicy", func() {
+	var current *networkingv1.NetworkPolicy
+
+	BeforeEach(func() {
+		current = &networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "mongodb-geo", Namespace: "hotel"},
+			Spec: networkingv1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"io.kompose.service": "mongodb-geo"}},
+				Ingress: []networkingv1.NetworkPolicyIngressRule{{
+					From: []networkingv1.NetworkPolicyPeer{{
+						PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"io.kompose.service": "geo"}},
+					}},
+				}},
+				PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			},
+		}
+	})
+
+	It("describes its kind", func() {
+		Expect(networkpolicies.From(nil).ResourceIsNil()).To(BeTrue())
+		Expect(networkpolicies.From(current).ResourceIsNil()).To(BeFalse())
+		Expect(networkpolicies.From(current).ResourceKind()).To(Equal("NetworkPolicy"))
+		Expect(networkpolicies.From(current).NewResourceInstance()).To(BeAssignableToTypeOf(&networkingv1.NetworkPolicy{}))
+	})
+
+	It("does not update an unchanged NetworkPolicy", func() {
+		update, _ := networkpolicies.From(current.DeepCopy()).ShouldUpdate(current)
+		Expect(update).To(BeFalse())
+	})
+
+	It("replaces the rules", func() {
+		desired := current.DeepCopy()
+		desired.Spec.Ingress = nil
+
+		update, updated := networkpolicies.From(desired).ShouldUpdate(current)
+		Expect(update).To(BeTrue())
+		Expect(updated.(*networkingv1.NetworkPolicy).Spec.Ingress).To(BeEmpty())
+	})
+
+	It("keeps the defaulted policy types when none are set", func() {
+		desired := current.DeepCopy()
+		desired.Spec.PolicyTypes = nil
+
+		update, updated := networkpolicies.From(desired).ShouldUpdate(current)
+		Expect(update).To(BeFalse())
+		Expect(updated.(*networkingv1.NetworkPolicy).Spec.PolicyTypes).To(Equal([]networkingv1.PolicyType{networkingv1.PolicyTypeIngress}))
+	})
+
+	It("replaces the policy types when some are set", func() {
+		desired := current.DeepCopy()
+		desired.Spec.PolicyTypes = []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress}
+
+		update, updated := networkpolicies.From(desired).ShouldUpdate(current)
+		Expect(update).To(BeTrue())
+		Expect(updated.(*networkingv1.NetworkPolicy).Spec.PolicyTypes).To(Equal(desired.Spec.PolicyTypes))
+	})
+})
@@ -0,0 +1,60 @@
+// ------------------------------------------------------ {COPYRIGHT-TOP} ---
+// IBM Confidential
+// OCO Source Materials
+// 5900-AEO
+//
+// Copyright IBM Corp. 2021
+//
+// The source code for this program is not published or otherwise
+// divested of its trade secrets, irrespective of what has been
+// deposited with the U.S. Copyright Office.
+// ------------------------------------------------------ {COPYRIGHT-END} ---
+
+package poddisruptionbudgets
+
+import (
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/resources"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// PodDisruptionBudget is a wrapper around the policyv1.PodDisruptionBudget object
+// that meets the Reconcileable interface
+type PodDisruptionBudget struct {
+	*policyv1.PodDisruptionBudget
+}
+
+// From returns a new Reconcileable PodDisruptionBudget from a policyv1.PodDisruptionBudget
+func From(pdb *policyv1.PodDisruptionBudget) *PodDisruptionBudget {
+	return &PodDisruptionBudget{PodDisruptionBudget: pdb}
+}
+
+// ShouldUpdate returns whether the resource should be updated in Kubernetes and
+// the resource to update with
+func (p PodDisruptionBudget) ShouldUpdate(current client.Object) (bool, client.Object) {
+	newPDB := current.DeepCopyObject().(*policyv1.PodDisruptionBudget)
+	resources.MergeMetadata(newPDB, p)
+	newPDB.Spec = p.Spec
+	return !equality.Semantic.DeepEqual(newPDB, current), newPDB
+}
+
+// GetResource retrieves the resource instance
+func (p PodDisruptionBudget) GetResource() client.Object {
+	return p.PodDisruptionBudget
+}
+
+// ResourceKind retrieves the string kind of the resource
+func (p PodDisruptionBudget) ResourceKind() string {
+	return "PodDisruptionBudget"
+}
+
+// ResourceIsNil returns whether or not the resource is nil
+func (p PodDisruptionBudget) ResourceIsNil() bool {
+	return p.PodDisruptionBudget == nil
+}
+
+// NewResourceInstance returns a new instance of the same resource type
+func (p PodDisruptionBudget) NewResourceInstance() client.Object {
+	return &policyv1.PodDisruptionBudget{}
+}
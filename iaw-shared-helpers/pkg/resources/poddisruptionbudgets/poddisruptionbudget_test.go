@@ -0,0 +1,62 @@
+// ------------------------------------------------------ {COPYRIGHT-TOP} ---
+// IBM Confidential
+// OCO Source Materials
+// 5900-AEO
+//
+// Copyright IBM Corp. 2021
+//
+// The source code for this program is not published or otherwise
+// divested of its trade secrets, irrespective of what has been
+// deposited with the U.S. Copyright Office.
+// ------------------------------------------------------ {COPYRIGHT-END} ---
+package poddisruptionbudgets_test
+
+import (
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/resources/poddisruptionbudgets"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+var _ = Describe("PodDisruptionBudget", func() {
+	var current *policyv1.PodDisruptionBudget
+
+	BeforeEach(func() {
+		maxUnavailable := intstr.FromInt(1)
+		current = &policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: "mongodb-geo", Namespace: "hotel"},
+			Spec: policyv1.PodDisruptionBudgetSpec{
+				MaxUnavailable: &maxUnavailable,
+				Selector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"io.kompose.service": "mongodb-geo"},
+				},
+			},
+		}
+	})
+
+	It("describes its kind", func() {
+		Expect(poddisruptionbudgets.From(nil).ResourceIsNil()).To(BeTrue())
+		Expect(poddisruptionbudgets.From(current).ResourceIsNil()).To(BeFalse())
+		Expect(poddisruptionbudgets.From(current).ResourceKind()).To(Equal("PodDisruptionBudget"))
+		Expect(poddisruptionbudgets.From(current).NewResourceInstance()).To(BeAssignableToTypeOf(&policyv1.PodDisruptionBudget{}))
+	})
+
+	It("does not update an unchanged PodDisruptionBudget", func() {
+		update, _ := poddisruptionbudgets.From(current.DeepCopy()).ShouldUpdate(current)
+		Expect(update).To(BeFalse())
+	})
+
+	It("switches from maxUnavailable to minAvailable", func() {
+		minAvailable := intstr.FromString("50%")
+		desired := current.DeepCopy()
+		desired.Spec.MaxUnavailable = nil
+		desired.Spec.MinAvailable = &minAvailable
+
+		update, updated := poddisruptionbudgets.From(desired).ShouldUpdate(current)
+		Expect(update).To(BeTrue())
+		Expect(updated.(*policyv1.PodDisruptionBudget).Spec.MaxUnavailable).To(BeNil())
+		Expect(updated.(*policyv1.PodDisruptionBudget).Spec.MinAvailable).To(Equal(&minAvailable))
+	})
+})
@@ -137,10 +137,11 @@ func (r *Reconciler) create(resourceType string, namespacedName types.Namespaced
 }
 
 // delete an instance of resourceType in Kube. If the object is successfully deleted returns the value of exitOnChange which indicates whether the
-// reconcile loop should exit. If the resource is being watched a new reconcile will be triggered by the deletion
+// reconcile loop should exit. If the resource is being watched a new reconcile will be triggered by the deletion. The dependents of the object
+// are deleted along with it, some kinds such as Jobs would otherwise orphan their pods
 func (r *Reconciler) delete(resourceType string, namespacedName types.NamespacedName, deleted client.Object, exitOnChange bool) (result ctrl.Result, exit bool, err error) {
 	r.Log.V(1).Info("Deleting", "resource type", resourceType, "NamespacedName", namespacedName)
-	err = r.Delete(r.Ctx, deleted)
+	err = r.Delete(r.Ctx, deleted, client.PropagationPolicy(metav1.DeletePropagationBackground))
 	if err != nil && errors.IsNotFound(err) {
 		// Already deleted, carry ononfigmap
 		return ctrl.Result{}, false, nil
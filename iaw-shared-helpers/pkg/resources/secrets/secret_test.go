@@ -0,0 +1,73 @@
+// ------------------------------------------------------ {COPYRIGHT-TOP} ---
+// IBM Confidential
+// OCO Source Materials
+// 5900-AEO
+//
+// Copyright IBM Corp. 2021
+//
+// The source code for this program is not published or otherwise
+// divested of its trade secrets, irrespective of what has been
+// deposited with the U.S. Copyright Office.
+// ------------------------------------------------------ {COPYRIGHT-END} ---
+package secrets_test
+
+import (
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/resources/secrets"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+var _ = Describe("Secret", func() {
+	var current *corev1.Secret
+
+	BeforeEach(func() {
+		current = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "mongodb-geo-credentials", Namespace: "hotel"},
+			Type:       corev1.SecretTypeOpaque,
+			Data:       map[string][]byte{"password": []byte("secret")},
+		}
+	})
+
+	It("describes its kind", func() {
+		Expect(secrets.From(nil).ResourceIsNil()).To(BeTrue())
+		Expect(secrets.From(current).ResourceIsNil()).To(BeFalse())
+		Expect(secrets.From(current).ResourceKind()).To(Equal("Secret"))
+		Expect(secrets.From(current).NewResourceInstance()).To(BeAssignableToTypeOf(&corev1.Secret{}))
+	})
+
+	It("does not update an unchanged Secret", func() {
+		update, _ := secrets.From(current.DeepCopy()).ShouldUpdate(current)
+		Expect(update).To(BeFalse())
+	})
+
+	It("replaces the data", func() {
+		desired := current.DeepCopy()
+		desired.Data = map[string][]byte{"password": []byte("rotated")}
+
+		update, updated := secrets.From(desired).ShouldUpdate(current)
+		Expect(update).To(BeTrue())
+		Expect(updated.(*corev1.Secret).Data).To(Equal(desired.Data))
+	})
+
+	It("keeps the current type", func() {
+		desired := current.DeepCopy()
+		desired.Type = ""
+
+		update, updated := secrets.From(desired).ShouldUpdate(current)
+		Expect(update).To(BeFalse())
+		Expect(updated.(*corev1.Secret).Type).To(Equal(corev1.SecretTypeOpaque))
+	})
+
+	It("keeps an immutable Secret immutable", func() {
+		current.Immutable = pointer.BoolPtr(true)
+		desired := current.DeepCopy()
+		desired.Immutable = pointer.BoolPtr(false)
+
+		update, updated := secrets.From(desired).ShouldUpdate(current)
+		Expect(update).To(BeFalse())
+		Expect(updated.(*corev1.Secret).Immutable).To(Equal(pointer.BoolPtr(true)))
+	})
+})
@@ -0,0 +1,65 @@
+// ------------------------------------------------------ {COPYRIGHT-TOP} ---
+// IBM Confidential
+// OCO Source Materials
+// 5900-AEO
+//
+// Copyright IBM Corp. 2021
+//
+// The source code for this program is not published or otherwise
+// divested of its trade secrets, irrespective of what has been
+// deposited with the U.S. Copyright Office.
+// ------------------------------------------------------ {COPYRIGHT-END} ---
+
+package serviceaccounts
+
+import (
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/resources"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ServiceAccount is a wrapper around the corev1.ServiceAccount object that meets the
+// Reconcileable interface
+type ServiceAccount struct {
+	*corev1.ServiceAccount
+}
+
+// From returns a new Reconcileable ServiceAccount from a corev1.ServiceAccount
+func From(serviceAccount *corev1.ServiceAccount) *ServiceAccount {
+	return &ServiceAccount{ServiceAccount: serviceAccount}
+}
+
+// ShouldUpdate returns whether the resource should be updated in Kubernetes and
+// the resource to update with. The token Secrets of a ServiceAccount are added by
+// Kube, so the current ones are kept unless some are set
+func (s ServiceAccount) ShouldUpdate(current client.Object) (bool, client.Object) {
+	newServiceAccount := current.DeepCopyObject().(*corev1.ServiceAccount)
+	resources.MergeMetadata(newServiceAccount, s)
+	newServiceAccount.ImagePullSecrets = s.ImagePullSecrets
+	newServiceAccount.AutomountServiceAccountToken = s.AutomountServiceAccountToken
+	if len(s.Secrets) != 0 {
+		newServiceAccount.Secrets = s.Secrets
+	}
+	return !equality.Semantic.DeepEqual(newServiceAccount, current), newServiceAccount
+}
+
+// GetResource retrieves the resource instance
+func (s ServiceAccount) GetResource() client.Object {
+	return s.ServiceAccount
+}
+
+// ResourceKind retrieves the string kind of the resource
+func (s ServiceAccount) ResourceKind() string {
+	return "ServiceAccount"
+}
+
+// ResourceIsNil returns whether or not the resource is nil
+func (s ServiceAccount) ResourceIsNil() bool {
+	return s.ServiceAccount == nil
+}
+
+// NewResourceInstance returns a new instance of the same resource type
+func (s ServiceAccount) NewResourceInstance() client.Object {
+	return &corev1.ServiceAccount{}
+}
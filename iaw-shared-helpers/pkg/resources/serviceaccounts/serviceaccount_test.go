@@ -0,0 +1,62 @@
+// ------------------------------------------------------ {COPYRIGHT-TOP} ---
+// IBM Confidential
+// OCO Source Materials
+// 5900-AEO
+//
+// Copyright IBM Corp. 2021
+//
+// The source code for this program is not published or otherwise
+// divested of its trade secrets, irrespective of what has been
+// deposited with the U.S. Copyright Office.
+// ------------------------------------------------------ {COPYRIGHT-END} ---
+package serviceaccounts_test
+
+import (
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/resources/serviceaccounts"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+var _ = Describe("ServiceAccount", func() {
+	var current *corev1.ServiceAccount
+
+	BeforeEach(func() {
+		current = &corev1.ServiceAccount{
+			ObjectMeta:       metav1.ObjectMeta{Name: "hotelreservation", Namespace: "hotel"},
+			Secrets:          []corev1.ObjectReference{{Name: "hotelreservation-token-x7k2p"}},
+			ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry"}},
+		}
+	})
+
+	It("describes its kind", func() {
+		Expect(serviceaccounts.From(nil).ResourceIsNil()).To(BeTrue())
+		Expect(serviceaccounts.From(current).ResourceIsNil()).To(BeFalse())
+		Expect(serviceaccounts.From(current).ResourceKind()).To(Equal("ServiceAccount"))
+		Expect(serviceaccounts.From(current).NewResourceInstance()).To(BeAssignableToTypeOf(&corev1.ServiceAccount{}))
+	})
+
+	It("keeps the token Secrets added by Kube", func() {
+		desired := current.DeepCopy()
+		desired.Secrets = nil
+
+		update, updated := serviceaccounts.From(desired).ShouldUpdate(current)
+		Expect(update).To(BeFalse())
+		Expect(updated.(*corev1.ServiceAccount).Secrets).To(Equal(current.Secrets))
+	})
+
+	It("replaces the image pull secrets and token automounting", func() {
+		desired := current.DeepCopy()
+		desired.Secrets = nil
+		desired.ImagePullSecrets = nil
+		desired.AutomountServiceAccountToken = pointer.BoolPtr(false)
+
+		update, updated := serviceaccounts.From(desired).ShouldUpdate(current)
+		Expect(update).To(BeTrue())
+		Expect(updated.(*corev1.ServiceAccount).ImagePullSecrets).To(BeEmpty())
+		Expect(updated.(*corev1.ServiceAccount).AutomountServiceAccountToken).To(Equal(pointer.BoolPtr(false)))
+		Expect(updated.(*corev1.ServiceAccount).Secrets).To(Equal(current.Secrets))
+	})
+})
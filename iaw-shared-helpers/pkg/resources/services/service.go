@@ -50,6 +50,22 @@ func (s Service) ShouldUpdate(current client.Object) (bool, client.Object) {
 	// ClusterIP is immutable so keep current value
 	currentService := current.DeepCopyObject().(*corev1.Service)
 	newService.Spec.ClusterIP = currentService.Spec.ClusterIP
+	newService.Spec.ClusterIPs = currentService.Spec.ClusterIPs
+
+	// NodePorts left unset are allocated by Kube, keep the allocated value rather than
+	// asking for a new one on every update
+	if newService.Spec.Type == corev1.ServiceTypeNodePort || newService.Spec.Type == corev1.ServiceTypeLoadBalancer {
+		for i, port := range newService.Spec.Ports {
+			if port.NodePort != 0 {
+				continue
+			}
+			for _, currentPort := range currentService.Spec.Ports {
+				if currentPort.Port == port.Port && currentPort.Protocol == port.Protocol {
+					newService.Spec.Ports[i].NodePort = currentPort.NodePort
+				}
+			}
+		}
+	}
 
 	return !equality.Semantic.DeepEqual(newService, current), newService
 }
@@ -0,0 +1,94 @@
+// ------------------------------------------------------ {COPYRIGHT-TOP} ---
+// IBM Confidential
+// OCO Source Materials
+// 5900-AEO
+//
+// Copyright IBM Corp. 2021
+//
+// The source code for this program is not published or otherwise
+// divested of its trade secrets, irrespective of what has been
+// deposited with the U.S. Copyright Office.
+// ------------------------------------------------------ {COPYRIGHT-END} ---
+package services_test
+
+import (
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/resources/services"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// service returns a Service of the given type exposing the given ports
+func service(serviceType corev1.ServiceType, ports ...corev1.ServicePort) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "frontend", Namespace: "hotel"},
+		Spec: corev1.ServiceSpec{
+			Type:     serviceType,
+			Ports:    ports,
+			Selector: map[string]string{"io.kompose.service": "frontend"},
+		},
+	}
+}
+
+// port returns a TCP port forwarding to itself on the given node port
+func port(number int32, nodePort int32) corev1.ServicePort {
+	return corev1.ServicePort{
+		Protocol:   corev1.ProtocolTCP,
+		Port:       number,
+		TargetPort: intstr.FromInt(int(number)),
+		NodePort:   nodePort,
+	}
+}
+
+// allocated returns a Service as read back from Kubernetes, with its ClusterIP allocated
+func allocated(service *corev1.Service) *corev1.Service {
+	service.Spec.ClusterIP = "10.96.0.10"
+	service.Spec.ClusterIPs = []string{"10.96.0.10"}
+	return service
+}
+
+var _ = Describe("Service", func() {
+	It("describes its kind", func() {
+		current := service(corev1.ServiceTypeClusterIP, port(5000, 0))
+		Expect(services.From(nil).ResourceIsNil()).To(BeTrue())
+		Expect(services.From(current).ResourceIsNil()).To(BeFalse())
+		Expect(services.From(current).ResourceKind()).To(Equal("Service"))
+		Expect(services.From(current).NewResourceInstance()).To(BeAssignableToTypeOf(&corev1.Service{}))
+	})
+
+	DescribeTable("ShouldUpdate",
+		func(desired *corev1.Service, current *corev1.Service, update bool, ports []corev1.ServicePort) {
+			shouldUpdate, updated := services.From(desired).ShouldUpdate(current)
+			Expect(shouldUpdate).To(Equal(update))
+			Expect(updated.(*corev1.Service).Spec.Ports).To(Equal(ports))
+			Expect(updated.(*corev1.Service).Spec.ClusterIP).To(Equal(current.Spec.ClusterIP))
+		},
+		Entry("does not update an unchanged Service",
+			service(corev1.ServiceTypeClusterIP, port(5000, 0)),
+			allocated(service(corev1.ServiceTypeClusterIP, port(5000, 0))),
+			false, []corev1.ServicePort{port(5000, 0)}),
+		Entry("pins a node port",
+			service(corev1.ServiceTypeNodePort, port(5000, 30080)),
+			allocated(service(corev1.ServiceTypeNodePort, port(5000, 31234))),
+			true, []corev1.ServicePort{port(5000, 30080)}),
+		Entry("keeps the node port allocated by Kubernetes",
+			service(corev1.ServiceTypeNodePort, port(5000, 0)),
+			allocated(service(corev1.ServiceTypeNodePort, port(5000, 31234))),
+			false, []corev1.ServicePort{port(5000, 31234)}),
+		Entry("clears the node port when switching NodePort to ClusterIP",
+			service(corev1.ServiceTypeClusterIP, port(5000, 0)),
+			allocated(service(corev1.ServiceTypeNodePort, port(5000, 31234))),
+			true, []corev1.ServicePort{port(5000, 0)}),
+		Entry("adds a port and keeps the allocated node port of the others",
+			service(corev1.ServiceTypeNodePort, port(5000, 0), port(8080, 0)),
+			allocated(service(corev1.ServiceTypeNodePort, port(5000, 31234))),
+			true, []corev1.ServicePort{port(5000, 31234), port(8080, 0)}),
+		Entry("defaults an unset target port to the port",
+			service(corev1.ServiceTypeClusterIP, corev1.ServicePort{Protocol: corev1.ProtocolTCP, Port: 5000}),
+			allocated(service(corev1.ServiceTypeClusterIP, port(5000, 0))),
+			false, []corev1.ServicePort{port(5000, 0)}),
+	)
+})
@@ -15,6 +15,7 @@ package statefulsets
 import (
 	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/resources"
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -31,14 +32,32 @@ func From(statefulset *appsv1.StatefulSet) *StatefulSet {
 }
 
 // ShouldUpdate returns whether the resource should be updated in Kubernetes and
-// the resource to update with
+// the resource to update with. The volume claim templates of a StatefulSet cannot be updated,
+// so the current ones are kept along with the pod volumes they provide
 func (s StatefulSet) ShouldUpdate(current client.Object) (bool, client.Object) {
-	newStatefulSet := current.DeepCopyObject().(*appsv1.StatefulSet)
+	currentStatefulSet := current.(*appsv1.StatefulSet)
+	newStatefulSet := currentStatefulSet.DeepCopy()
 	resources.MergeMetadata(newStatefulSet, s)
 	resources.MergeMetadata(&newStatefulSet.Spec.Template, &s.Spec.Template)
 	mergedTemplate := newStatefulSet.Spec.Template
-	newStatefulSet.Spec = s.Spec
+	newStatefulSet.Spec = *s.Spec.DeepCopy()
 	newStatefulSet.Spec.Template.ObjectMeta = mergedTemplate.ObjectMeta
+	newStatefulSet.Spec.VolumeClaimTemplates = currentStatefulSet.Spec.VolumeClaimTemplates
+
+	claimed := map[string]struct{}{}
+	for _, template := range currentStatefulSet.Spec.VolumeClaimTemplates {
+		claimed[template.Name] = struct{}{}
+	}
+	volumes := []corev1.Volume{}
+	for _, volume := range newStatefulSet.Spec.Template.Spec.Volumes {
+		if _, ok := claimed[volume.Name]; !ok {
+			volumes = append(volumes, volume)
+		}
+	}
+	if len(volumes) == 0 {
+		volumes = nil
+	}
+	newStatefulSet.Spec.Template.Spec.Volumes = volumes
 	return !equality.Semantic.DeepEqual(newStatefulSet, current), newStatefulSet
 }
 
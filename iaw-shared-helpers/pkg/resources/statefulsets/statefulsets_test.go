@@ -0,0 +1,96 @@
+// ------------------------------------------------------ {COPYRIGHT-TOP} ---
+// IBM Confidential
+// OCO Source Materials
+// 5900-AEO
+//
+// Copyright IBM Corp. 2021
+//
+// The source code for this program is not published or otherwise
+// divested of its trade secrets, irrespective of what has been
+// deposited with the U.S. Copyright Office.
+// ------------------------------------------------------ {COPYRIGHT-END} ---
+package statefulsets_test
+
+import (
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/resources/statefulsets"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+// claimTemplate returns a volume claim template of the given name
+func claimTemplate(name string) corev1.PersistentVolumeClaim {
+	return corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       corev1.PersistentVolumeClaimSpec{AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}},
+	}
+}
+
+// emptyDir returns an emptyDir volume of the given name
+func emptyDir(name string) corev1.Volume {
+	return corev1.Volume{Name: name, VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}}
+}
+
+// statefulSet returns a mongodb StatefulSet with the given replicas, volume claim templates
+// and pod volumes
+func statefulSet(replicas int32, templates []corev1.PersistentVolumeClaim, volumes []corev1.Volume) *appsv1.StatefulSet {
+	return &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "mongodb-geo", Namespace: "hotel"},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: pointer.Int32Ptr(replicas),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"io.kompose.service": "mongodb-geo"}},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "hotel-reserv-geo-mongo", Image: "mongo:4.4.6"}},
+					Volumes:    volumes,
+				},
+			},
+			VolumeClaimTemplates: templates,
+		},
+	}
+}
+
+var _ = Describe("StatefulSet", func() {
+	It("describes its kind", func() {
+		current := statefulSet(1, nil, nil)
+		Expect(statefulsets.From(nil).ResourceIsNil()).To(BeTrue())
+		Expect(statefulsets.From(current).ResourceIsNil()).To(BeFalse())
+		Expect(statefulsets.From(current).ResourceKind()).To(Equal("StatefulSet"))
+		Expect(statefulsets.From(current).NewResourceInstance()).To(BeAssignableToTypeOf(&appsv1.StatefulSet{}))
+	})
+
+	DescribeTable("ShouldUpdate",
+		func(desired *appsv1.StatefulSet, current *appsv1.StatefulSet, update bool, replicas int32, templates []corev1.PersistentVolumeClaim, volumes []corev1.Volume) {
+			shouldUpdate, updated := statefulsets.From(desired).ShouldUpdate(current)
+			Expect(shouldUpdate).To(Equal(update))
+			spec := updated.(*appsv1.StatefulSet).Spec
+			Expect(*spec.Replicas).To(Equal(replicas))
+			Expect(spec.VolumeClaimTemplates).To(Equal(templates))
+			Expect(spec.Template.Spec.Volumes).To(Equal(volumes))
+		},
+		Entry("does not update an unchanged StatefulSet",
+			statefulSet(1, []corev1.PersistentVolumeClaim{claimTemplate("geo")}, nil),
+			statefulSet(1, []corev1.PersistentVolumeClaim{claimTemplate("geo")}, nil),
+			false, int32(1), []corev1.PersistentVolumeClaim{claimTemplate("geo")}, nil),
+		Entry("updates the replicas",
+			statefulSet(3, []corev1.PersistentVolumeClaim{claimTemplate("geo")}, nil),
+			statefulSet(1, []corev1.PersistentVolumeClaim{claimTemplate("geo")}, nil),
+			true, int32(3), []corev1.PersistentVolumeClaim{claimTemplate("geo")}, nil),
+		Entry("keeps the current volume claim templates",
+			statefulSet(1, []corev1.PersistentVolumeClaim{claimTemplate("data")}, nil),
+			statefulSet(1, []corev1.PersistentVolumeClaim{claimTemplate("geo")}, nil),
+			false, int32(1), []corev1.PersistentVolumeClaim{claimTemplate("geo")}, nil),
+		Entry("keeps an emptyDir StatefulSet without claims",
+			statefulSet(1, nil, []corev1.Volume{emptyDir("geo")}),
+			statefulSet(1, nil, []corev1.Volume{emptyDir("geo")}),
+			false, int32(1), nil, []corev1.Volume{emptyDir("geo")}),
+		Entry("drops the pod volumes shadowing a current claim template",
+			statefulSet(1, nil, []corev1.Volume{emptyDir("geo"), emptyDir("scratch")}),
+			statefulSet(1, []corev1.PersistentVolumeClaim{claimTemplate("geo")}, nil),
+			true, int32(1), []corev1.PersistentVolumeClaim{claimTemplate("geo")}, []corev1.Volume{emptyDir("scratch")}),
+	)
+})
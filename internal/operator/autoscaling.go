@@ -0,0 +1,85 @@
+package operator
+
+import (
+	examplev1beta1 "github.com/Youngpig1998/hotelreservation-operator/api/v1beta1"
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/resources"
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/resources/horizontalpodautoscalers"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+// defaultTargetCPUUtilization is the average CPU utilization the autoscalers aim for when the
+// spec sets none
+const defaultTargetCPUUtilization = 80
+
+// Autoscaled returns whether the replica count of a logic service is managed by a
+// HorizontalPodAutoscaler
+func Autoscaled(app *examplev1beta1.HotelReservationApp, name string) bool {
+	return componentSpec(app, name).Autoscaling != nil
+}
+
+// HorizontalPodAutoscaler returns the HorizontalPodAutoscaler scaling the Deployment of a logic
+// service on the CPU utilization of its pods and on its custom metric. The returned resource is
+// nil when the service is not autoscaled so that an existing one is pruned
+func HorizontalPodAutoscaler(component Component, app *examplev1beta1.HotelReservationApp) resources.Reconcileable {
+	autoscaling := componentSpec(app, component.Name).Autoscaling
+	if autoscaling == nil {
+		return horizontalpodautoscalers.From(nil)
+	}
+
+	targetCPU := int32(defaultTargetCPUUtilization)
+	if autoscaling.TargetCPUUtilizationPercentage != nil {
+		targetCPU = *autoscaling.TargetCPUUtilizationPercentage
+	}
+	metrics := []autoscalingv2.MetricSpec{{
+		Type: autoscalingv2.ResourceMetricSourceType,
+		Resource: &autoscalingv2.ResourceMetricSource{
+			Name: corev1.ResourceCPU,
+			Target: autoscalingv2.MetricTarget{
+				Type:               autoscalingv2.UtilizationMetricType,
+				AverageUtilization: pointer.Int32Ptr(targetCPU),
+			},
+		},
+	}}
+	if custom := autoscaling.CustomMetric; custom != nil {
+		averageValue := custom.TargetAverageValue.DeepCopy()
+		metrics = append(metrics, autoscalingv2.MetricSpec{
+			Type: autoscalingv2.PodsMetricSourceType,
+			Pods: &autoscalingv2.PodsMetricSource{
+				Metric: autoscalingv2.MetricIdentifier{Name: custom.Name},
+				Target: autoscalingv2.MetricTarget{
+					Type:         autoscalingv2.AverageValueMetricType,
+					AverageValue: &averageValue,
+				},
+			},
+		})
+	}
+
+	minReplicas := int32(1)
+	if autoscaling.MinReplicas != nil {
+		minReplicas = *autoscaling.MinReplicas
+	}
+
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: component.Name,
+			Labels: map[string]string{
+				"io.kompose.service": component.Name,
+			},
+		},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
+				APIVersion: appsv1.SchemeGroupVersion.String(),
+				Kind:       KindDeployment,
+				Name:       component.Name,
+			},
+			MinReplicas: pointer.Int32Ptr(minReplicas),
+			MaxReplicas: autoscaling.MaxReplicas,
+			Metrics:     metrics,
+		},
+	}
+	return horizontalpodautoscalers.From(hpa)
+}
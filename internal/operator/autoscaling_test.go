@@ -0,0 +1,70 @@
+package operator
+
+import (
+	examplev1beta1 "github.com/Youngpig1998/hotelreservation-operator/api/v1beta1"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/pointer"
+)
+
+var _ = Describe("Autoscaling", func() {
+	It("renders no HorizontalPodAutoscaler for a service that is not autoscaled", func() {
+		Expect(HorizontalPodAutoscaler(catalogueComponent("search"), newApp(nil)).ResourceIsNil()).To(BeTrue())
+	})
+
+	It("scales the Deployment of the service on its CPU utilization by default", func() {
+		app := newApp(map[string]examplev1beta1.ComponentSpec{"search": {Autoscaling: &examplev1beta1.AutoscalingSpec{MaxReplicas: 5}}})
+		hpa := HorizontalPodAutoscaler(catalogueComponent("search"), app).GetResource().(*autoscalingv2.HorizontalPodAutoscaler)
+		Expect(hpa.Name).To(Equal("search"))
+		Expect(hpa.Spec.ScaleTargetRef).To(Equal(autoscalingv2.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: KindDeployment, Name: "search"}))
+		Expect(hpa.Spec.MinReplicas).To(Equal(pointer.Int32Ptr(1)))
+		Expect(hpa.Spec.MaxReplicas).To(Equal(int32(5)))
+		Expect(hpa.Spec.Metrics).To(Equal([]autoscalingv2.MetricSpec{{
+			Type: autoscalingv2.ResourceMetricSourceType,
+			Resource: &autoscalingv2.ResourceMetricSource{
+				Name:   corev1.ResourceCPU,
+				Target: autoscalingv2.MetricTarget{Type: autoscalingv2.UtilizationMetricType, AverageUtilization: pointer.Int32Ptr(defaultTargetCPUUtilization)},
+			},
+		}}))
+	})
+
+	It("renders the replica limits, the CPU target and the custom metric of the spec", func() {
+		app := newApp(map[string]examplev1beta1.ComponentSpec{"frontend": {Autoscaling: &examplev1beta1.AutoscalingSpec{
+			MinReplicas:                    pointer.Int32Ptr(2),
+			MaxReplicas:                    10,
+			TargetCPUUtilizationPercentage: pointer.Int32Ptr(60),
+			CustomMetric:                   &examplev1beta1.CustomMetricSpec{Name: "http_requests_per_second", TargetAverageValue: resource.MustParse("100")},
+		}}})
+		hpa := HorizontalPodAutoscaler(catalogueComponent("frontend"), app).GetResource().(*autoscalingv2.HorizontalPodAutoscaler)
+		Expect(hpa.Spec.MinReplicas).To(Equal(pointer.Int32Ptr(2)))
+		Expect(hpa.Spec.MaxReplicas).To(Equal(int32(10)))
+		Expect(hpa.Spec.Metrics).To(HaveLen(2))
+		Expect(hpa.Spec.Metrics[0].Resource.Target.AverageUtilization).To(Equal(pointer.Int32Ptr(60)))
+		Expect(hpa.Spec.Metrics[1].Type).To(Equal(autoscalingv2.PodsMetricSourceType))
+		Expect(hpa.Spec.Metrics[1].Pods.Metric.Name).To(Equal("http_requests_per_second"))
+		Expect(hpa.Spec.Metrics[1].Pods.Target.Type).To(Equal(autoscalingv2.AverageValueMetricType))
+		Expect(hpa.Spec.Metrics[1].Pods.Target.AverageValue.String()).To(Equal("100"))
+	})
+
+	DescribeTable("Deployment of a logic service",
+		func(components map[string]examplev1beta1.ComponentSpec, replicas *int32, strategy appsv1.DeploymentStrategyType) {
+			deployment := DeploymentForLogic(catalogueComponent("search"), newApp(components), nil).GetResource().(*appsv1.Deployment)
+			Expect(deployment.Spec.Replicas).To(Equal(replicas))
+			Expect(deployment.Spec.Strategy.Type).To(Equal(strategy))
+		},
+		Entry("runs one replica recreated on updates by default", nil, pointer.Int32Ptr(1), appsv1.RecreateDeploymentStrategyType),
+		Entry("runs the replicas of the spec",
+			map[string]examplev1beta1.ComponentSpec{"search": {Replicas: pointer.Int32Ptr(3)}}, pointer.Int32Ptr(3), appsv1.RecreateDeploymentStrategyType),
+		Entry("leaves the replicas to the autoscaler and rolls the pods progressively",
+			map[string]examplev1beta1.ComponentSpec{"search": {Replicas: pointer.Int32Ptr(3), Autoscaling: &examplev1beta1.AutoscalingSpec{MaxReplicas: 5}}},
+			nil, appsv1.RollingUpdateDeploymentStrategyType),
+		Entry("keeps the replicas of a service when another one is autoscaled",
+			map[string]examplev1beta1.ComponentSpec{"geo": {Autoscaling: &examplev1beta1.AutoscalingSpec{MaxReplicas: 5}}},
+			pointer.Int32Ptr(1), appsv1.RecreateDeploymentStrategyType),
+	)
+})
@@ -0,0 +1,178 @@
+package operator
+
+import (
+	"fmt"
+	"path"
+
+	examplev1beta1 "github.com/Youngpig1998/hotelreservation-operator/api/v1beta1"
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/resources"
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/resources/cronjobs"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+const (
+	// BackupUploaderImage is the default image of the S3 client uploading the backups
+	BackupUploaderImage = "amazon/aws-cli"
+	// defaultBackupRetention is the number of backups kept per mongodb instance when the spec sets none
+	defaultBackupRetention = 7
+	// defaultBackupRegion is the region of the S3 bucket when the spec sets none
+	defaultBackupRegion = "us-east-1"
+	// backupMountPath is the directory the backups are written to in the backup pods
+	backupMountPath = "/backup"
+	// backupArchive is the name of a dump waiting to be uploaded to S3
+	backupArchive = "archive.gz"
+	// AWS credentials keys of the Secret referenced by an S3 backup target
+	awsAccessKeyIDKey     = "AWS_ACCESS_KEY_ID"
+	awsSecretAccessKeyKey = "AWS_SECRET_ACCESS_KEY"
+)
+
+// dumpScript runs mongodump against the instance given in $HOST into the archive given as its
+// first argument, authenticating as the root user once the instance has one
+const dumpScript = `set -e
+set -- --quiet --host "$HOST" --gzip --archive="$1"
+if [ -n "$ROOT_PASSWORD" ]; then set -- "$@" --username "$ROOT_USERNAME" --password "$ROOT_PASSWORD" --authenticationDatabase admin; fi
+mongodump "$@"`
+
+// pvcBackupScript dumps the instance into a timestamped archive of $BACKUP_DIR and removes the
+// archives beyond the $RETENTION most recent ones. The archive is only renamed into place once
+// complete so that a failed dump never counts towards the retention
+const pvcBackupScript = `set -e
+mkdir -p "$BACKUP_DIR"
+rm -f "$BACKUP_DIR"/*.partial
+archive="$BACKUP_DIR/$(date -u +%Y%m%dT%H%M%SZ).archive.gz"
+sh -c '` + dumpScript + `' dump "$archive.partial"
+mv "$archive.partial" "$archive"
+ls -1 "$BACKUP_DIR"/*.archive.gz | sort -r | tail -n +$((RETENTION + 1)) | while read -r old; do rm -f "$old"; done`
+
+// s3BackupScript uploads the archive dumped by the init container under a timestamped key of
+// $BACKUP_PREFIX and removes the objects beyond the $RETENTION most recent ones
+const s3BackupScript = `set -e
+set -- ${ENDPOINT:+--endpoint-url "$ENDPOINT"}
+aws "$@" s3 cp "` + backupMountPath + "/" + backupArchive + `" "s3://$BUCKET/$BACKUP_PREFIX/$(date -u +%Y%m%dT%H%M%SZ).archive.gz"
+aws "$@" s3 ls "s3://$BUCKET/$BACKUP_PREFIX/" | while read -r _ _ _ key; do echo "$key"; done | grep '\.archive\.gz$' | sort -r | tail -n +$((RETENTION + 1)) | while read -r old; do aws "$@" s3 rm "s3://$BUCKET/$BACKUP_PREFIX/$old"; done`
+
+// BackupCronJobName returns the name of the CronJob backing up a mongodb instance
+func BackupCronJobName(name string) string {
+	return name + "-backup"
+}
+
+// backupRetention returns the number of backups kept per mongodb instance
+func backupRetention(backup *examplev1beta1.BackupSpec) int32 {
+	if backup.Retention > 0 {
+		return backup.Retention
+	}
+	return defaultBackupRetention
+}
+
+// BackupCronJob returns the CronJob dumping a mongodb instance on the schedule of the spec into
+// its backup target. A PersistentVolumeClaim target is written to directly, while a dump to S3
+// is made by an init container into a scratch volume the uploader then copies to the bucket.
+// The returned resource is nil when backups are not enabled so that an existing one is pruned
+func BackupCronJob(app *examplev1beta1.HotelReservationApp, name string) resources.Reconcileable {
+	backup := app.Spec.Backup
+	if backup == nil {
+		return cronjobs.From(nil)
+	}
+	spec := componentSpec(app, name)
+
+	env := []corev1.EnvVar{
+		{Name: "HOST", Value: shellHost(app, name)},
+		{Name: "RETENTION", Value: fmt.Sprint(backupRetention(backup))},
+	}
+	if AuthEnabled(app) {
+		// The root password is missing until the users of the instance are created, which is
+		// also when the instance starts requiring authentication
+		env = append(env,
+			secretKeyEnv("ROOT_USERNAME", CredentialsSecretName(name), RootUsernameKey, true),
+			secretKeyEnv("ROOT_PASSWORD", CredentialsSecretName(name), RootPasswordKey, true),
+		)
+	}
+	dump := corev1.Container{
+		Name:            "backup",
+		Image:           imageFor(app, spec, MongoImage, ""),
+		ImagePullPolicy: pullPolicyFor(spec),
+		Env:             env,
+		VolumeMounts:    []corev1.VolumeMount{{Name: "backup", MountPath: backupMountPath}},
+	}
+
+	podSpec := corev1.PodSpec{
+		ImagePullSecrets: app.Spec.ImagePullSecrets,
+		RestartPolicy:    corev1.RestartPolicyOnFailure,
+	}
+	if target := backup.Target.PersistentVolumeClaim; target != nil {
+		dump.Command = []string{"sh", "-c", pvcBackupScript}
+		dump.Env = append(dump.Env, corev1.EnvVar{Name: "BACKUP_DIR", Value: path.Join(backupMountPath, target.Path, name)})
+		podSpec.Containers = []corev1.Container{dump}
+		podSpec.Volumes = []corev1.Volume{{
+			Name: "backup",
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: target.ClaimName},
+			},
+		}}
+	} else if target := backup.Target.S3; target != nil {
+		dump.Name = "dump"
+		dump.Command = []string{"sh", "-c", dumpScript, "dump", path.Join(backupMountPath, backupArchive)}
+		region := target.Region
+		if region == "" {
+			region = defaultBackupRegion
+		}
+		uploaderSpec := examplev1beta1.ComponentSpec{Image: target.Image, ImagePullPolicy: spec.ImagePullPolicy}
+		podSpec.InitContainers = []corev1.Container{dump}
+		podSpec.Containers = []corev1.Container{{
+			Name:            "upload",
+			Image:           imageFor(app, uploaderSpec, BackupUploaderImage, ""),
+			ImagePullPolicy: pullPolicyFor(uploaderSpec),
+			Command:         []string{"sh", "-c", s3BackupScript},
+			Env: []corev1.EnvVar{
+				{Name: "ENDPOINT", Value: target.Endpoint},
+				{Name: "BUCKET", Value: target.Bucket},
+				{Name: "BACKUP_PREFIX", Value: path.Join(target.Prefix, name)},
+				{Name: "RETENTION", Value: fmt.Sprint(backupRetention(backup))},
+				{Name: "AWS_DEFAULT_REGION", Value: region},
+				secretKeyEnv(awsAccessKeyIDKey, target.CredentialsSecretName, awsAccessKeyIDKey, false),
+				secretKeyEnv(awsSecretAccessKeyKey, target.CredentialsSecretName, awsSecretAccessKeyKey, false),
+			},
+			VolumeMounts: []corev1.VolumeMount{{Name: "backup", MountPath: backupMountPath, ReadOnly: true}},
+		}}
+		podSpec.Volumes = []corev1.Volume{{
+			Name: "backup",
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		}}
+	}
+	applyPlacement(&podSpec, placementFor(app, name, TierData))
+
+	cronJob := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: BackupCronJobName(name),
+			Labels: map[string]string{
+				"io.kompose.service": BackupCronJobName(name),
+			},
+		},
+		Spec: batchv1.CronJobSpec{
+			Schedule:                   backup.Schedule,
+			Suspend:                    pointer.BoolPtr(backup.Suspend),
+			ConcurrencyPolicy:          batchv1.ForbidConcurrent,
+			SuccessfulJobsHistoryLimit: pointer.Int32Ptr(1),
+			FailedJobsHistoryLimit:     pointer.Int32Ptr(1),
+			JobTemplate: batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					BackoffLimit: pointer.Int32Ptr(2),
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: map[string]string{
+								"io.kompose.service": BackupCronJobName(name),
+							},
+						},
+						Spec: podSpec,
+					},
+				},
+			},
+		},
+	}
+	return cronjobs.From(cronJob)
+}
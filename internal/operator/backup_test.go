@@ -0,0 +1,73 @@
+package operator
+
+import (
+	examplev1beta1 "github.com/Youngpig1998/hotelreservation-operator/api/v1beta1"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// backupApp returns a HotelReservationApp backing up its mongodb instances to the given target
+func backupApp(target examplev1beta1.BackupTarget) *examplev1beta1.HotelReservationApp {
+	app := newApp(nil)
+	app.Spec.Backup = &examplev1beta1.BackupSpec{Schedule: "0 2 * * *", Target: target}
+	return app
+}
+
+// envValue returns the value of the named environment variable of a container
+func envValue(container corev1.Container, name string) string {
+	for _, env := range container.Env {
+		if env.Name == name {
+			return env.Value
+		}
+	}
+	return ""
+}
+
+var _ = Describe("Backup", func() {
+	It("creates no CronJob unless backups are enabled", func() {
+		Expect(BackupCronJob(newApp(nil), "mongodb-geo").ResourceIsNil()).To(BeTrue())
+	})
+
+	It("keeps seven backups unless the spec sets a retention", func() {
+		Expect(backupRetention(&examplev1beta1.BackupSpec{})).To(Equal(int32(7)))
+		Expect(backupRetention(&examplev1beta1.BackupSpec{Retention: 3})).To(Equal(int32(3)))
+	})
+
+	It("dumps an instance into a directory of the PersistentVolumeClaim target", func() {
+		app := backupApp(examplev1beta1.BackupTarget{PersistentVolumeClaim: &examplev1beta1.PVCBackupTarget{ClaimName: "backups", Path: "hotel"}})
+		cronJob := BackupCronJob(app, "mongodb-geo").GetResource().(*batchv1.CronJob)
+		Expect(cronJob.Name).To(Equal("mongodb-geo-backup"))
+		Expect(cronJob.Spec.Schedule).To(Equal("0 2 * * *"))
+		Expect(cronJob.Spec.ConcurrencyPolicy).To(Equal(batchv1.ForbidConcurrent))
+
+		podSpec := cronJob.Spec.JobTemplate.Spec.Template.Spec
+		Expect(podSpec.InitContainers).To(BeEmpty())
+		Expect(podSpec.Containers).To(HaveLen(1))
+		Expect(envValue(podSpec.Containers[0], "HOST")).To(Equal("mongodb-geo.hotel.svc:27017"))
+		Expect(envValue(podSpec.Containers[0], "BACKUP_DIR")).To(Equal("/backup/hotel/mongodb-geo"))
+		Expect(podSpec.Volumes[0].PersistentVolumeClaim.ClaimName).To(Equal("backups"))
+	})
+
+	It("dumps an instance into a scratch volume uploaded to the S3 target", func() {
+		app := backupApp(examplev1beta1.BackupTarget{S3: &examplev1beta1.S3BackupTarget{Bucket: "backups", Prefix: "hotel", CredentialsSecretName: "aws"}})
+		podSpec := BackupCronJob(app, "mongodb-geo").GetResource().(*batchv1.CronJob).Spec.JobTemplate.Spec.Template.Spec
+		Expect(podSpec.InitContainers).To(HaveLen(1))
+		Expect(podSpec.InitContainers[0].Name).To(Equal("dump"))
+		Expect(podSpec.Containers).To(HaveLen(1))
+		upload := podSpec.Containers[0]
+		Expect(upload.Image).To(Equal(BackupUploaderImage))
+		Expect(envValue(upload, "BUCKET")).To(Equal("backups"))
+		Expect(envValue(upload, "BACKUP_PREFIX")).To(Equal("hotel/mongodb-geo"))
+		Expect(envValue(upload, "AWS_DEFAULT_REGION")).To(Equal("us-east-1"))
+		Expect(podSpec.Volumes[0].EmptyDir).NotTo(BeNil())
+	})
+
+	It("authenticates the dump as the root user when authentication is enabled", func() {
+		app := backupApp(examplev1beta1.BackupTarget{PersistentVolumeClaim: &examplev1beta1.PVCBackupTarget{ClaimName: "backups"}})
+		app.Spec.MongoDB = &examplev1beta1.MongoDBSpec{Auth: &examplev1beta1.MongoDBAuthSpec{}}
+		dump := BackupCronJob(app, "mongodb-geo").GetResource().(*batchv1.CronJob).Spec.JobTemplate.Spec.Template.Spec.Containers[0]
+		Expect(dump.Env).To(ContainElement(secretKeyEnv("ROOT_PASSWORD", "mongodb-geo-credentials", RootPasswordKey, true)))
+	})
+})
@@ -0,0 +1,167 @@
+package operator
+
+import (
+	"fmt"
+
+	examplev1beta1 "github.com/Youngpig1998/hotelreservation-operator/api/v1beta1"
+)
+
+// Workload kinds backing the components of the application
+const (
+	KindDeployment  = "Deployment"
+	KindStatefulSet = "StatefulSet"
+)
+
+// FrontendPort is the HTTP port of the frontend
+const FrontendPort int32 = 5000
+
+// Component describes a logic service of the hotel reservation application. The operator
+// derives every resource of the service from its entry in the Catalogue: its Deployment and
+// Service, its memcached and mongodb instances and its entries in config.json
+type Component struct {
+	// Name of the service, also the name of its Deployment and Service and the command run by
+	// its container
+	Name string
+	// Tier of the service, TierFrontend for the entrypoint of the application or TierLogic
+	Tier string
+	// Image is the default image repository of the service
+	Image string
+	// Port is the port the service listens on
+	Port int32
+	// ConfigPrefix is the prefix of the service's entries in config.json
+	ConfigPrefix string
+	// NeedsCache is true when the service is backed by a memcached-<name> instance
+	NeedsCache bool
+	// NeedsDB is true when the service is backed by a mongodb-<name> instance
+	NeedsDB bool
+	// Dependencies are the names of the services it calls
+	Dependencies []string
+}
+
+// CacheName returns the name of the memcached instance backing the service
+func (c Component) CacheName() string {
+	return "memcached-" + c.Name
+}
+
+// DBName returns the name of the mongodb instance backing the service
+func (c Component) DBName() string {
+	return "mongodb-" + c.Name
+}
+
+// Catalogue lists the logic services of the application in the order they are reconciled.
+// Adding a service to the application only takes a new entry here
+var Catalogue = []Component{
+	{Name: "reservation", Tier: TierLogic, Image: HotelReservationImage, Port: 8087, ConfigPrefix: "Reserve", NeedsCache: true, NeedsDB: true},
+	{Name: "rate", Tier: TierLogic, Image: HotelReservationImage, Port: 8084, ConfigPrefix: "Rate", NeedsCache: true, NeedsDB: true},
+	{Name: "profile", Tier: TierLogic, Image: HotelReservationImage, Port: 8081, ConfigPrefix: "Profile", NeedsCache: true, NeedsDB: true},
+	{Name: "geo", Tier: TierLogic, Image: HotelReservationImage, Port: 8083, ConfigPrefix: "Geo", NeedsDB: true},
+	{Name: "recommendation", Tier: TierLogic, Image: HotelReservationImage, Port: 8085, ConfigPrefix: "Recommend", NeedsDB: true},
+	{Name: "user", Tier: TierLogic, Image: HotelReservationImage, Port: 8086, ConfigPrefix: "User", NeedsDB: true},
+	{Name: "search", Tier: TierLogic, Image: HotelReservationImage, Port: 8082, ConfigPrefix: "Search",
+		Dependencies: []string{"geo", "rate"}},
+	{Name: "frontend", Tier: TierFrontend, Image: HotelReservationImage, Port: FrontendPort, ConfigPrefix: "Frontend",
+		Dependencies: []string{"search", "profile", "recommendation", "user", "reservation"}},
+}
+
+// EnabledComponents returns the services of the Catalogue that are not disabled in the spec
+func EnabledComponents(app *examplev1beta1.HotelReservationApp) []Component {
+	enabled := []Component{}
+	for _, component := range Catalogue {
+		if spec := componentSpec(app, component.Name); spec.Enabled == nil || *spec.Enabled {
+			enabled = append(enabled, component)
+		}
+	}
+	return enabled
+}
+
+// Workloads returns the name and kind of the workload of every component run for the app:
+// the memcached and mongodb instances and the Deployment of each enabled service, consul and jaeger
+func Workloads(app *examplev1beta1.HotelReservationApp) map[string]string {
+	workloads := map[string]string{
+		"consul": KindDeployment,
+		"jaeger": KindDeployment,
+	}
+	for _, component := range EnabledComponents(app) {
+		if component.NeedsCache {
+			workloads[component.CacheName()] = CacheKind(app, component.CacheName())
+		}
+		if component.NeedsDB {
+			workloads[component.DBName()] = KindStatefulSet
+		}
+		workloads[component.Name] = KindDeployment
+	}
+	return workloads
+}
+
+// InfrastructureWorkloads returns the names of the workloads that have to be ready before any
+// service is rolled out: consul, jaeger and the memcached and mongodb instances
+func InfrastructureWorkloads(app *examplev1beta1.HotelReservationApp) []string {
+	workloads := []string{"consul", "jaeger"}
+	for _, component := range EnabledComponents(app) {
+		if component.NeedsCache {
+			workloads = append(workloads, component.CacheName())
+		}
+		if component.NeedsDB {
+			workloads = append(workloads, component.DBName())
+		}
+	}
+	return workloads
+}
+
+// EnabledDependencies returns the services a service calls that are enabled in the spec. The
+// disabled ones are reported by DependencyErrors instead, waiting for them would hold up the
+// rollout of the service forever
+func EnabledDependencies(app *examplev1beta1.HotelReservationApp, component Component) []string {
+	enabled := map[string]struct{}{}
+	for _, enabledComponent := range EnabledComponents(app) {
+		enabled[enabledComponent.Name] = struct{}{}
+	}
+	dependencies := []string{}
+	for _, dependency := range component.Dependencies {
+		if _, ok := enabled[dependency]; ok {
+			dependencies = append(dependencies, dependency)
+		}
+	}
+	return dependencies
+}
+
+// DependencyErrors reports the enabled services calling a service that is disabled in the spec
+func DependencyErrors(app *examplev1beta1.HotelReservationApp) map[string]error {
+	enabled := map[string]struct{}{}
+	for _, component := range EnabledComponents(app) {
+		enabled[component.Name] = struct{}{}
+	}
+
+	dependencyErrors := map[string]error{}
+	for _, component := range EnabledComponents(app) {
+		for _, dependency := range component.Dependencies {
+			if _, ok := enabled[dependency]; !ok {
+				dependencyErrors[component.Name] = fmt.Errorf("depends on disabled service %s", dependency)
+			}
+		}
+	}
+	return dependencyErrors
+}
+
+// EnabledDatabases returns the names of the mongodb instances of the enabled services
+func EnabledDatabases(app *examplev1beta1.HotelReservationApp) []string {
+	names := []string{}
+	for _, component := range EnabledComponents(app) {
+		if component.NeedsDB {
+			names = append(names, component.DBName())
+		}
+	}
+	return names
+}
+
+// DatabaseNames returns the names of the mongodb instances of every service of the Catalogue,
+// including the disabled ones whose data may have been left behind
+func DatabaseNames() []string {
+	names := []string{}
+	for _, component := range Catalogue {
+		if component.NeedsDB {
+			names = append(names, component.DBName())
+		}
+	}
+	return names
+}
@@ -0,0 +1,129 @@
+package operator
+
+import (
+	examplev1beta1 "github.com/Youngpig1998/hotelreservation-operator/api/v1beta1"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/pointer"
+)
+
+// disabled is a component override leaving the component out of the application
+var disabled = examplev1beta1.ComponentSpec{Enabled: pointer.BoolPtr(false)}
+
+// catalogueComponent returns the entry of the Catalogue with the given name
+func catalogueComponent(name string) Component {
+	for _, component := range Catalogue {
+		if component.Name == name {
+			return component
+		}
+	}
+	Fail("no component named " + name)
+	return Component{}
+}
+
+// componentNames returns the names of the given components
+func componentNames(components []Component) []string {
+	names := []string{}
+	for _, component := range components {
+		names = append(names, component.Name)
+	}
+	return names
+}
+
+var _ = Describe("Catalogue", func() {
+	DescribeTable("EnabledComponents",
+		func(components map[string]examplev1beta1.ComponentSpec, expected []string) {
+			Expect(componentNames(EnabledComponents(newApp(components)))).To(Equal(expected))
+		},
+		Entry("keeps every service in the order of the Catalogue by default", nil,
+			[]string{"reservation", "rate", "profile", "geo", "recommendation", "user", "search", "frontend"}),
+		Entry("leaves out the disabled services",
+			map[string]examplev1beta1.ComponentSpec{"search": disabled, "frontend": disabled},
+			[]string{"reservation", "rate", "profile", "geo", "recommendation", "user"}),
+		Entry("keeps the services explicitly enabled",
+			map[string]examplev1beta1.ComponentSpec{"geo": {Enabled: pointer.BoolPtr(true)}, "user": disabled},
+			[]string{"reservation", "rate", "profile", "geo", "recommendation", "search", "frontend"}),
+	)
+
+	DescribeTable("DependencyErrors",
+		func(components map[string]examplev1beta1.ComponentSpec, expected map[string]string) {
+			dependencyErrors := map[string]string{}
+			for name, err := range DependencyErrors(newApp(components)) {
+				dependencyErrors[name] = err.Error()
+			}
+			Expect(dependencyErrors).To(Equal(expected))
+		},
+		Entry("reports nothing when every service is enabled", nil, map[string]string{}),
+		Entry("reports the services calling a disabled service",
+			map[string]examplev1beta1.ComponentSpec{"geo": disabled},
+			map[string]string{"search": "depends on disabled service geo"}),
+		Entry("reports nothing for a disabled service calling a disabled service",
+			map[string]examplev1beta1.ComponentSpec{"geo": disabled, "search": disabled, "frontend": disabled},
+			map[string]string{}),
+	)
+
+	DescribeTable("Workloads",
+		func(components map[string]examplev1beta1.ComponentSpec, memcached *examplev1beta1.MemcachedSpec, expected map[string]string) {
+			app := newApp(components)
+			app.Spec.Memcached = memcached
+			Expect(Workloads(app)).To(Equal(expected))
+		},
+		Entry("lists the workloads of every service by default", nil, nil, map[string]string{
+			"consul": KindDeployment, "jaeger": KindDeployment,
+			"memcached-reservation": KindDeployment, "memcached-rate": KindDeployment, "memcached-profile": KindDeployment,
+			"mongodb-reservation": KindStatefulSet, "mongodb-rate": KindStatefulSet, "mongodb-profile": KindStatefulSet,
+			"mongodb-geo": KindStatefulSet, "mongodb-recommendation": KindStatefulSet, "mongodb-user": KindStatefulSet,
+			"reservation": KindDeployment, "rate": KindDeployment, "profile": KindDeployment, "geo": KindDeployment,
+			"recommendation": KindDeployment, "user": KindDeployment, "search": KindDeployment, "frontend": KindDeployment,
+		}),
+		Entry("leaves out the workloads of the disabled services",
+			map[string]examplev1beta1.ComponentSpec{
+				"reservation": disabled, "rate": disabled, "profile": disabled, "recommendation": disabled, "user": disabled, "frontend": disabled,
+			}, nil, map[string]string{
+				"consul": KindDeployment, "jaeger": KindDeployment,
+				"mongodb-geo": KindStatefulSet, "geo": KindDeployment, "search": KindDeployment,
+			}),
+		Entry("runs the memcached instances with several replicas as StatefulSets",
+			map[string]examplev1beta1.ComponentSpec{
+				"reservation": disabled, "profile": disabled, "geo": disabled, "recommendation": disabled, "user": disabled, "search": disabled, "frontend": disabled,
+			}, &examplev1beta1.MemcachedSpec{Replicas: pointer.Int32Ptr(2)}, map[string]string{
+				"consul": KindDeployment, "jaeger": KindDeployment,
+				"memcached-rate": KindStatefulSet, "mongodb-rate": KindStatefulSet, "rate": KindDeployment,
+			}),
+	)
+	DescribeTable("InfrastructureWorkloads",
+		func(components map[string]examplev1beta1.ComponentSpec, expected []string) {
+			Expect(InfrastructureWorkloads(newApp(components))).To(Equal(expected))
+		},
+		Entry("lists consul, jaeger and the instances of every service by default", nil, []string{
+			"consul", "jaeger",
+			"memcached-reservation", "mongodb-reservation", "memcached-rate", "mongodb-rate", "memcached-profile", "mongodb-profile",
+			"mongodb-geo", "mongodb-recommendation", "mongodb-user",
+		}),
+		Entry("leaves out the instances of the disabled services",
+			map[string]examplev1beta1.ComponentSpec{"reservation": disabled, "rate": disabled, "profile": disabled, "recommendation": disabled},
+			[]string{"consul", "jaeger", "mongodb-geo", "mongodb-user"}),
+	)
+	It("lists the mongodb instances of every service", func() {
+		Expect(DatabaseNames()).To(Equal([]string{
+			"mongodb-reservation", "mongodb-rate", "mongodb-profile", "mongodb-geo", "mongodb-recommendation", "mongodb-user",
+		}))
+	})
+	It("lists the mongodb instances of the enabled services", func() {
+		app := newApp(map[string]examplev1beta1.ComponentSpec{"geo": disabled, "user": disabled})
+		Expect(EnabledDatabases(app)).To(Equal([]string{"mongodb-reservation", "mongodb-rate", "mongodb-profile", "mongodb-recommendation"}))
+	})
+
+	DescribeTable("EnabledDependencies",
+		func(components map[string]examplev1beta1.ComponentSpec, name string, expected []string) {
+			Expect(EnabledDependencies(newApp(components), catalogueComponent(name))).To(Equal(expected))
+		},
+		Entry("keeps every dependency when all are enabled", nil, "search", []string{"geo", "rate"}),
+		Entry("leaves out a disabled dependency", map[string]examplev1beta1.ComponentSpec{"search": disabled},
+			"frontend", []string{"profile", "recommendation", "user", "reservation"}),
+		Entry("returns none when every dependency is disabled",
+			map[string]examplev1beta1.ComponentSpec{"geo": disabled, "rate": disabled}, "search", []string{}),
+		Entry("returns none for a service without dependencies", nil, "geo", []string{}),
+	)
+})
@@ -0,0 +1,130 @@
+package operator
+
+import (
+	"strings"
+
+	examplev1beta1 "github.com/Youngpig1998/hotelreservation-operator/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/pointer"
+)
+
+// Default image repositories and tags of the components, used when the spec does not override them
+const (
+	MongoImage            = "mongo"
+	MemcachedImage        = "memcached"
+	ConsulImage           = "consul"
+	JaegerImage           = "jaegertracing/all-in-one"
+	JaegerTag             = "latest"
+	HotelReservationImage = "youngpig/hotel_reservation"
+)
+
+// Default resource requests of each kind of component, no limits are set unless the spec asks for them
+var (
+	mongoResources     = requests("100m", "256Mi")
+	memcachedResources = requests("100m", "64Mi")
+	logicResources     = requests("100m", "64Mi")
+	consulResources    = requests("100m", "128Mi")
+	jaegerResources    = requests("100m", "128Mi")
+)
+
+func requests(cpu string, memory string) corev1.ResourceRequirements {
+	return corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(cpu),
+			corev1.ResourceMemory: resource.MustParse(memory),
+		},
+	}
+}
+
+// componentSpec returns the overrides the HotelReservationApp declares for the named component
+func componentSpec(app *examplev1beta1.HotelReservationApp, name string) examplev1beta1.ComponentSpec {
+	return app.Spec.Components[name]
+}
+
+// replicasFor returns the number of replicas to run for a component, defaulting to one
+func replicasFor(spec examplev1beta1.ComponentSpec) *int32 {
+	if spec.Replicas != nil {
+		return pointer.Int32Ptr(*spec.Replicas)
+	}
+	return pointer.Int32Ptr(1)
+}
+
+// imageFor returns the image of a component built from its default repository and tag and
+// the overrides in its spec. Images without a registry host are pulled from the
+// DockerRegistryPrefix of the HotelReservationApp when one is set, which stands in for the
+// registry host only so that the full repository path of the image is kept. A digest in the
+// spec pins the image in place of its tag
+func imageFor(app *examplev1beta1.HotelReservationApp, spec examplev1beta1.ComponentSpec, defaultImage string, defaultTag string) string {
+	image := defaultImage
+	if spec.Image != "" {
+		image = spec.Image
+	}
+
+	prefix := strings.TrimSpace(app.Spec.DockerRegistryPrefix)
+	if len(prefix) > 0 && !hasRegistryHost(image) {
+		image = strings.TrimSuffix(prefix, "/") + "/" + image
+	}
+
+	if spec.Digest != "" {
+		return image + "@" + spec.Digest
+	}
+	tag := defaultTag
+	if spec.Tag != "" {
+		tag = spec.Tag
+	}
+	if tag == "" {
+		return image
+	}
+	return image + ":" + tag
+}
+
+// hasRegistryHost returns whether the first component of an image repository names a registry,
+// following the docker convention that a host contains a '.' or a ':' or is localhost
+func hasRegistryHost(image string) bool {
+	parts := strings.SplitN(image, "/", 2)
+	if len(parts) == 1 {
+		return false
+	}
+	return strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost"
+}
+
+// pullPolicyFor returns the image pull policy of a component, defaulting to IfNotPresent
+func pullPolicyFor(spec examplev1beta1.ComponentSpec) corev1.PullPolicy {
+	if spec.ImagePullPolicy != "" {
+		return spec.ImagePullPolicy
+	}
+	return corev1.PullIfNotPresent
+}
+
+// customiseContainer applies the resources and environment overrides of a component to its
+// main container, falling back to defaultResources when the spec sets none
+func customiseContainer(container *corev1.Container, spec examplev1beta1.ComponentSpec, defaultResources corev1.ResourceRequirements) {
+	container.Resources = *defaultResources.DeepCopy()
+	if spec.Resources != nil {
+		container.Resources = *spec.Resources.DeepCopy()
+	}
+	container.Env = mergeEnv(container.Env, spec.Env)
+}
+
+// mergeEnv appends overrides to env, replacing the variables of env that share a name with
+// one of the overrides
+func mergeEnv(env []corev1.EnvVar, overrides []corev1.EnvVar) []corev1.EnvVar {
+	if len(overrides) == 0 {
+		return env
+	}
+	merged := []corev1.EnvVar{}
+	overridden := map[string]struct{}{}
+	for _, override := range overrides {
+		overridden[override.Name] = struct{}{}
+	}
+	for _, envVar := range env {
+		if _, ok := overridden[envVar.Name]; !ok {
+			merged = append(merged, envVar)
+		}
+	}
+	for _, override := range overrides {
+		merged = append(merged, *override.DeepCopy())
+	}
+	return merged
+}
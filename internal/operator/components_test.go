@@ -0,0 +1,93 @@
+package operator
+
+import (
+	examplev1beta1 "github.com/Youngpig1998/hotelreservation-operator/api/v1beta1"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+// newApp returns a HotelReservationApp with the given component overrides
+func newApp(components map[string]examplev1beta1.ComponentSpec) *examplev1beta1.HotelReservationApp {
+	return &examplev1beta1.HotelReservationApp{
+		ObjectMeta: metav1.ObjectMeta{Name: "hotel", Namespace: "hotel"},
+		Spec:       examplev1beta1.HotelReservationAppSpec{Components: components},
+	}
+}
+
+var _ = Describe("Components", func() {
+	DescribeTable("imageFor",
+		func(prefix string, spec examplev1beta1.ComponentSpec, defaultImage string, defaultTag string, expected string) {
+			app := newApp(nil)
+			app.Spec.DockerRegistryPrefix = prefix
+			Expect(imageFor(app, spec, defaultImage, defaultTag)).To(Equal(expected))
+		},
+		Entry("uses the default image and tag", "", examplev1beta1.ComponentSpec{}, JaegerImage, JaegerTag, "jaegertracing/all-in-one:latest"),
+		Entry("leaves the tag out when there is none", "", examplev1beta1.ComponentSpec{}, MongoImage, "", "mongo"),
+		Entry("overrides the image and tag", "", examplev1beta1.ComponentSpec{Image: "bitnami/mongodb", Tag: "5.0"}, MongoImage, "", "bitnami/mongodb:5.0"),
+		Entry("overrides the tag of the default image", "", examplev1beta1.ComponentSpec{Tag: "1.6"}, MemcachedImage, "", "memcached:1.6"),
+		Entry("pins the digest in place of the tag", "", examplev1beta1.ComponentSpec{Tag: "5.0", Digest: "sha256:abc"}, MongoImage, "4.4", "mongo@sha256:abc"),
+		Entry("pulls from the registry prefix", "registry.example.com/hotel/", examplev1beta1.ComponentSpec{}, HotelReservationImage, "",
+			"registry.example.com/hotel/youngpig/hotel_reservation"),
+		Entry("keeps the repository path behind the registry prefix", "docker.io", examplev1beta1.ComponentSpec{}, JaegerImage, JaegerTag,
+			"docker.io/jaegertracing/all-in-one:latest"),
+		Entry("trims the registry prefix", " registry.example.com ", examplev1beta1.ComponentSpec{Tag: "v1"}, MemcachedImage, "",
+			"registry.example.com/memcached:v1"),
+		Entry("keeps an image naming its registry", "registry.example.com", examplev1beta1.ComponentSpec{Image: "quay.io/hotel/geo"}, HotelReservationImage, "",
+			"quay.io/hotel/geo"),
+	)
+
+	DescribeTable("hasRegistryHost",
+		func(image string, expected bool) {
+			Expect(hasRegistryHost(image)).To(Equal(expected))
+		},
+		Entry("an official image", "mongo", false),
+		Entry("a Docker Hub repository", "jaegertracing/all-in-one", false),
+		Entry("a host with a domain", "quay.io/hotel/geo", true),
+		Entry("a host with a port", "registry:5000/geo", true),
+		Entry("localhost", "localhost/geo", true),
+	)
+
+	DescribeTable("replicasFor",
+		func(replicas *int32, expected int32) {
+			Expect(*replicasFor(examplev1beta1.ComponentSpec{Replicas: replicas})).To(Equal(expected))
+		},
+		Entry("defaults to one replica", nil, int32(1)),
+		Entry("keeps the replicas of the spec", pointer.Int32Ptr(3), int32(3)),
+		Entry("keeps zero replicas", pointer.Int32Ptr(0), int32(0)),
+	)
+
+	DescribeTable("pullPolicyFor",
+		func(policy corev1.PullPolicy, expected corev1.PullPolicy) {
+			Expect(pullPolicyFor(examplev1beta1.ComponentSpec{ImagePullPolicy: policy})).To(Equal(expected))
+		},
+		Entry("defaults to IfNotPresent", corev1.PullPolicy(""), corev1.PullIfNotPresent),
+		Entry("keeps the policy of the spec", corev1.PullAlways, corev1.PullAlways),
+	)
+
+	DescribeTable("mergeEnv",
+		func(env []corev1.EnvVar, overrides []corev1.EnvVar, expected []corev1.EnvVar) {
+			Expect(mergeEnv(env, overrides)).To(Equal(expected))
+		},
+		Entry("keeps the environment without overrides",
+			[]corev1.EnvVar{{Name: "A", Value: "1"}}, nil, []corev1.EnvVar{{Name: "A", Value: "1"}}),
+		Entry("replaces the overridden variables and appends the others",
+			[]corev1.EnvVar{{Name: "A", Value: "1"}, {Name: "B", Value: "2"}},
+			[]corev1.EnvVar{{Name: "B", Value: "3"}, {Name: "C", Value: "4"}},
+			[]corev1.EnvVar{{Name: "A", Value: "1"}, {Name: "B", Value: "3"}, {Name: "C", Value: "4"}}),
+	)
+
+	It("applies the resources of the spec in place of the defaults", func() {
+		container := corev1.Container{Env: []corev1.EnvVar{{Name: "A", Value: "1"}}}
+		customiseContainer(&container, examplev1beta1.ComponentSpec{}, logicResources)
+		Expect(container.Resources).To(Equal(logicResources))
+
+		limits := corev1.ResourceRequirements{Limits: requests("1", "1Gi").Requests}
+		customiseContainer(&container, examplev1beta1.ComponentSpec{Resources: &limits, Env: []corev1.EnvVar{{Name: "A", Value: "2"}}}, logicResources)
+		Expect(container.Resources).To(Equal(limits))
+		Expect(container.Env).To(Equal([]corev1.EnvVar{{Name: "A", Value: "2"}}))
+	})
+})
@@ -0,0 +1,119 @@
+package operator
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+
+	examplev1beta1 "github.com/Youngpig1998/hotelreservation-operator/api/v1beta1"
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/resources"
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/resources/configmaps"
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/resources/secrets"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ConfigMapName is the name of the ConfigMap, or of the Secret when mongodb authentication is
+	// enabled, holding the configuration of the logic services
+	ConfigMapName = "hotelreservation-config"
+	// ConfigFileName is the file the hotel reservation services read their configuration from
+	ConfigFileName = "config.json"
+	// ConfigHashAnnotation is set on the logic pod templates so that they roll when the config changes
+	ConfigHashAnnotation = "example.njtech.edu.cn/config-hash"
+	// configMountPath is the directory the hotel reservation services look for ConfigFileName in
+	configMountPath = "/go/src/github.com/harlow/go-micro-services/config"
+)
+
+// ServiceConfig renders the config.json of the hotel reservation services from the
+// HotelReservationApp: the port of each service and the addresses of consul, jaeger and of
+// the memcached and mongodb instances backing the services, along with the credentials of the
+// mongodb instances requiring authentication
+func ServiceConfig(app *examplev1beta1.HotelReservationApp, credentials MongoCredentials) string {
+	config := map[string]string{
+		"consulAddress":     addressOf(app, "consul", 8500, app.Spec.LogicNodeIp, 8500),
+		"jaegerAddress":     addressOf(app, "jaeger", 6831, app.Spec.LogicNodeIp, 6831),
+		"KnativeDomainName": "example.com",
+	}
+	for _, component := range EnabledComponents(app) {
+		config[component.ConfigPrefix+"Port"] = fmt.Sprint(component.Port)
+		if component.NeedsCache {
+			config[component.ConfigPrefix+"MemcAddress"] = cacheAddresses(app, component.CacheName())
+		}
+		if component.NeedsDB {
+			address := mongoAddress(app, component.DBName())
+			if dbCredentials, ok := credentials[component.DBName()]; ok {
+				address = withCredentials(address, dbCredentials)
+			}
+			config[component.ConfigPrefix+"MongoAddress"] = address
+		}
+	}
+
+	// Maps are marshalled with sorted keys so the rendered config is stable between reconciles
+	rendered, _ := json.MarshalIndent(config, "", "  ")
+	return string(rendered)
+}
+
+// ConfigHash returns the hash of a rendered config, used to roll the pods reading it
+func ConfigHash(config string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(config)))
+}
+
+// ConfigMapForServices returns the ConfigMap holding the config.json of the logic services. The
+// returned resource is nil when mongodb authentication is enabled, the config then holds
+// credentials and is stored in the Secret of ConfigSecretForServices instead
+func ConfigMapForServices(app *examplev1beta1.HotelReservationApp, credentials MongoCredentials) resources.Reconcileable {
+	if AuthEnabled(app) {
+		return configmaps.From(nil)
+	}
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: ConfigMapName,
+			Labels: map[string]string{
+				"io.kompose.service": ConfigMapName,
+			},
+		},
+		Data: map[string]string{
+			ConfigFileName: ServiceConfig(app, credentials),
+		},
+	}
+
+	return configmaps.From(configMap)
+}
+
+// ConfigSecretForServices returns the Secret holding the config.json of the logic services when
+// mongodb authentication is enabled, the returned resource is nil otherwise
+func ConfigSecretForServices(app *examplev1beta1.HotelReservationApp, credentials MongoCredentials) resources.Reconcileable {
+	if !AuthEnabled(app) {
+		return secrets.From(nil)
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: ConfigMapName,
+			Labels: map[string]string{
+				"io.kompose.service": ConfigMapName,
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			ConfigFileName: []byte(ServiceConfig(app, credentials)),
+		},
+	}
+	return secrets.From(secret)
+}
+
+// configVolumeSource returns the source of the volume the logic services read their config from
+func configVolumeSource(app *examplev1beta1.HotelReservationApp) corev1.VolumeSource {
+	if AuthEnabled(app) {
+		return corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{SecretName: ConfigMapName},
+		}
+	}
+	return corev1.VolumeSource{
+		ConfigMap: &corev1.ConfigMapVolumeSource{
+			LocalObjectReference: corev1.LocalObjectReference{
+				Name: ConfigMapName,
+			},
+		},
+	}
+}
@@ -0,0 +1,80 @@
+package operator
+
+import (
+	"encoding/json"
+
+	examplev1beta1 "github.com/Youngpig1998/hotelreservation-operator/api/v1beta1"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// renderedConfig returns the entries of the config.json rendered for the app
+func renderedConfig(app *examplev1beta1.HotelReservationApp, credentials MongoCredentials) map[string]string {
+	config := map[string]string{}
+	Expect(json.Unmarshal([]byte(ServiceConfig(app, credentials)), &config)).To(Succeed())
+	return config
+}
+
+var _ = Describe("ServiceConfig", func() {
+	It("renders the Service DNS addresses in InCluster mode", func() {
+		config := renderedConfig(newApp(nil), nil)
+		Expect(config).To(HaveKeyWithValue("consulAddress", "consul.hotel.svc:8500"))
+		Expect(config).To(HaveKeyWithValue("jaegerAddress", "jaeger.hotel.svc:6831"))
+		Expect(config).To(HaveKeyWithValue("KnativeDomainName", "example.com"))
+		Expect(config).To(HaveKeyWithValue("FrontendPort", "5000"))
+		Expect(config).To(HaveKeyWithValue("SearchPort", "8082"))
+		Expect(config).To(HaveKeyWithValue("ReserveMemcAddress", "memcached-reservation.hotel.svc:11211"))
+		Expect(config).To(HaveKeyWithValue("GeoMongoAddress", "mongodb-geo.hotel.svc:27017"))
+		Expect(config).NotTo(HaveKey("GeoMemcAddress"))
+		Expect(config).NotTo(HaveKey("SearchMongoAddress"))
+	})
+
+	It("renders the node addresses in NodeIP mode", func() {
+		config := renderedConfig(nodeIPApp(nil), nil)
+		Expect(config).To(HaveKeyWithValue("consulAddress", "10.0.0.1:8500"))
+		Expect(config).To(HaveKeyWithValue("jaegerAddress", "10.0.0.1:6831"))
+		Expect(config).To(HaveKeyWithValue("RateMemcAddress", "10.0.0.2:31001"))
+		Expect(config).To(HaveKeyWithValue("UserMongoAddress", "10.0.0.2:30006"))
+	})
+
+	It("leaves out the entries of the disabled services", func() {
+		config := renderedConfig(newApp(map[string]examplev1beta1.ComponentSpec{"rate": disabled}), nil)
+		Expect(config).NotTo(HaveKey("RatePort"))
+		Expect(config).NotTo(HaveKey("RateMemcAddress"))
+		Expect(config).NotTo(HaveKey("RateMongoAddress"))
+		Expect(config).To(HaveKey("ProfilePort"))
+	})
+
+	It("adds the credentials to the addresses of the instances that have them", func() {
+		credentials := MongoCredentials{"mongodb-geo": {Username: "geo", Password: "p@ss"}}
+		config := renderedConfig(newApp(nil), credentials)
+		Expect(config).To(HaveKeyWithValue("GeoMongoAddress", "geo:p%40ss@mongodb-geo.hotel.svc:27017/?authSource=admin"))
+		Expect(config).To(HaveKeyWithValue("UserMongoAddress", "mongodb-user.hotel.svc:27017"))
+	})
+
+	It("renders the same config for the same app", func() {
+		Expect(ServiceConfig(newApp(nil), nil)).To(Equal(ServiceConfig(newApp(nil), nil)))
+		Expect(ConfigHash(ServiceConfig(newApp(nil), nil))).To(Equal(ConfigHash(ServiceConfig(newApp(nil), nil))))
+		Expect(ConfigHash(ServiceConfig(newApp(nil), nil))).NotTo(Equal(ConfigHash(ServiceConfig(nodeIPApp(nil), nil))))
+	})
+	It("stores the config in a ConfigMap", func() {
+		app := newApp(nil)
+		Expect(ConfigSecretForServices(app, nil).ResourceIsNil()).To(BeTrue())
+		configMap := ConfigMapForServices(app, nil).GetResource().(*corev1.ConfigMap)
+		Expect(configMap.Name).To(Equal(ConfigMapName))
+		Expect(configMap.Data).To(HaveKeyWithValue(ConfigFileName, ServiceConfig(app, nil)))
+		Expect(configVolumeSource(app).ConfigMap.Name).To(Equal(ConfigMapName))
+	})
+
+	It("stores the config in a Secret when it holds credentials", func() {
+		app := newApp(nil)
+		app.Spec.MongoDB = &examplev1beta1.MongoDBSpec{Auth: &examplev1beta1.MongoDBAuthSpec{}}
+		credentials := MongoCredentials{"mongodb-geo": {Username: "geo", Password: "secret"}}
+		Expect(ConfigMapForServices(app, credentials).ResourceIsNil()).To(BeTrue())
+		secret := ConfigSecretForServices(app, credentials).GetResource().(*corev1.Secret)
+		Expect(secret.Name).To(Equal(ConfigMapName))
+		Expect(secret.Data).To(HaveKeyWithValue(ConfigFileName, []byte(ServiceConfig(app, credentials))))
+		Expect(configVolumeSource(app).Secret.SecretName).To(Equal(ConfigMapName))
+	})
+})
@@ -0,0 +1,334 @@
+package operator
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+
+	examplev1beta1 "github.com/Youngpig1998/hotelreservation-operator/api/v1beta1"
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/resources"
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/resources/jobs"
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/resources/secrets"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+// Keys of the credentials Secret of a mongodb instance. The pending passwords are the ones of a
+// rotation the users have not been updated with yet, they replace the current ones once the
+// credentials Job of the rotation succeeds. Each rotation gives the services a new user so that
+// the previous one stays valid for the pods still running with it, it is dropped once the
+// services have rolled out with the new one
+const (
+	RootUsernameKey        = "root-username"
+	RootPasswordKey        = "root-password"
+	UsernameKey            = "username"
+	PasswordKey            = "password"
+	PendingRootPasswordKey = "pending-root-password"
+	PendingUsernameKey     = "pending-username"
+	PendingPasswordKey     = "pending-password"
+	PreviousUsernameKey    = "previous-username"
+)
+
+// CredentialsRotationAnnotation is set on the credentials Secrets to the rotation their
+// passwords were generated for
+const CredentialsRotationAnnotation = "example.njtech.edu.cn/credentials-rotation"
+
+// Default usernames of the users created in every mongodb instance. The services' user
+// alternates between appUsername and rotatedAppUsername from one rotation to the next
+const (
+	rootUsername       = "root"
+	appUsername        = "hotelreservation"
+	rotatedAppUsername = "hotelreservation-rotated"
+)
+
+// passwordAlphabet only holds characters that need no escaping in connection strings or scripts
+const passwordAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// Credentials are the active credentials of a mongodb instance, the users of the instance exist
+// with these passwords
+type Credentials struct {
+	RootUsername string
+	RootPassword string
+	Username     string
+	Password     string
+}
+
+// MongoCredentials maps the name of each mongodb instance to its active credentials. Instances
+// missing from it run without authentication
+type MongoCredentials map[string]Credentials
+
+// AuthEnabled returns whether the mongodb instances of the app require authentication
+func AuthEnabled(app *examplev1beta1.HotelReservationApp) bool {
+	return app.Spec.MongoDB != nil && app.Spec.MongoDB.Auth != nil
+}
+
+// CredentialsRotation returns the rotation of the credentials requested in the spec
+func CredentialsRotation(app *examplev1beta1.HotelReservationApp) int64 {
+	if !AuthEnabled(app) {
+		return 0
+	}
+	return app.Spec.MongoDB.Auth.Rotation
+}
+
+// CredentialsSecretName returns the name of the Secret holding the credentials of a mongodb instance
+func CredentialsSecretName(name string) string {
+	return name + "-credentials"
+}
+
+// CredentialsJobName returns the name of the Job updating the users of a mongodb instance with
+// the passwords of a rotation
+func CredentialsJobName(name string, rotation int64) string {
+	return fmt.Sprintf("%s-credentials-%d", name, rotation)
+}
+
+// CredentialsCleanupJobName returns the name of the Job dropping the services' user a rotation
+// replaced
+func CredentialsCleanupJobName(name string, rotation int64) string {
+	return fmt.Sprintf("%s-credentials-%d-cleanup", name, rotation)
+}
+
+// GeneratePassword returns a new random password
+func GeneratePassword() (string, error) {
+	password := make([]byte, 32)
+	for i := range password {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(passwordAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		password[i] = passwordAlphabet[n.Int64()]
+	}
+	return string(password), nil
+}
+
+// NewCredentialsData returns the content of a new credentials Secret, whose users and passwords
+// are all pending since the users do not exist yet
+func NewCredentialsData() (map[string][]byte, error) {
+	data := map[string][]byte{
+		RootUsernameKey: []byte(rootUsername),
+	}
+	if err := RotateCredentialsData(data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// RotateCredentialsData generates new pending passwords into the content of a credentials
+// Secret, along with the services' user they are for, which is not the current one
+func RotateCredentialsData(data map[string][]byte) error {
+	for _, key := range []string{PendingRootPasswordKey, PendingPasswordKey} {
+		password, err := GeneratePassword()
+		if err != nil {
+			return err
+		}
+		data[key] = []byte(password)
+	}
+	data[PendingUsernameKey] = []byte(appUsername)
+	if string(data[UsernameKey]) == appUsername {
+		data[PendingUsernameKey] = []byte(rotatedAppUsername)
+	}
+	return nil
+}
+
+// PromoteCredentialsData makes the pending user and passwords of the content of a credentials
+// Secret the current ones, once the users have been updated with them. The services' user they
+// replace becomes the previous one, left in place until the services stop using it
+func PromoteCredentialsData(data map[string][]byte) {
+	if previous, ok := data[UsernameKey]; ok && len(data[PasswordKey]) != 0 {
+		data[PreviousUsernameKey] = previous
+	}
+	data[RootPasswordKey] = data[PendingRootPasswordKey]
+	data[UsernameKey] = data[PendingUsernameKey]
+	data[PasswordKey] = data[PendingPasswordKey]
+	delete(data, PendingRootPasswordKey)
+	delete(data, PendingUsernameKey)
+	delete(data, PendingPasswordKey)
+}
+
+// ForgetPreviousCredentialsData removes the previous services' user from the content of a
+// credentials Secret, once it has been dropped
+func ForgetPreviousCredentialsData(data map[string][]byte) {
+	delete(data, PreviousUsernameKey)
+}
+
+// CredentialsFromData returns the active credentials held by the content of a credentials
+// Secret, ok is false when the users have not been created yet
+func CredentialsFromData(data map[string][]byte) (credentials Credentials, ok bool) {
+	credentials = Credentials{
+		RootUsername: string(data[RootUsernameKey]),
+		RootPassword: string(data[RootPasswordKey]),
+		Username:     string(data[UsernameKey]),
+		Password:     string(data[PasswordKey]),
+	}
+	return credentials, credentials.RootPassword != "" && credentials.Password != ""
+}
+
+// CredentialsSecret returns the Secret holding the credentials of a mongodb instance
+func CredentialsSecret(app *examplev1beta1.HotelReservationApp, name string, data map[string][]byte, rotation int64) resources.Reconcileable {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: CredentialsSecretName(name),
+			Labels: map[string]string{
+				"io.kompose.service": name,
+			},
+			Annotations: map[string]string{
+				CredentialsRotationAnnotation: fmt.Sprint(rotation),
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: data,
+	}
+	return secrets.From(secret)
+}
+
+// CredentialsJob returns the Job creating or updating the root user and the pending services'
+// user of a mongodb instance with the pending passwords of its credentials Secret. The current
+// services' user is left untouched. It authenticates with the current root password when there
+// is one, the instance runs without authentication until its users have been created
+func CredentialsJob(app *examplev1beta1.HotelReservationApp, name string, rotation int64) resources.Reconcileable {
+	spec := componentSpec(app, name)
+
+	// The services' user is updated first since updating the root user invalidates the
+	// password the script authenticated with
+	upsert := `function upsert(user, pwd, roles) {
+  var admin = db.getSiblingDB("admin");
+  if (admin.getUser(user) === null) { admin.createUser({user: user, pwd: pwd, roles: roles}) } else { admin.updateUser(user, {pwd: pwd, roles: roles}) }
+}
+upsert("'"$PENDING_USERNAME"'", "'"$PENDING_PASSWORD"'", [{role: "readWriteAnyDatabase", db: "admin"}]);
+upsert("'"$ROOT_USERNAME"'", "'"$PENDING_ROOT_PASSWORD"'", [{role: "root", db: "admin"}]);`
+	script := fmt.Sprintf(`shell=mongosh; command -v mongosh >/dev/null || shell=mongo
+set -- --quiet --host '%s'
+if [ -n "$ROOT_PASSWORD" ]; then set -- "$@" --username "$ROOT_USERNAME" --password "$ROOT_PASSWORD" --authenticationDatabase admin; fi
+until $shell "$@" --eval 'db.adminCommand("ping")'; do sleep 5; done
+$shell "$@" --eval '%s'`, shellHost(app, name), upsert)
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: CredentialsJobName(name, rotation),
+			Labels: map[string]string{
+				"io.kompose.service": CredentialsJobName(name, rotation),
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: pointer.Int32Ptr(6),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"io.kompose.service": CredentialsJobName(name, rotation),
+					},
+				},
+				Spec: corev1.PodSpec{
+					ImagePullSecrets: app.Spec.ImagePullSecrets,
+					RestartPolicy:    corev1.RestartPolicyOnFailure,
+					Containers: []corev1.Container{{
+						Name:            "credentials",
+						Image:           imageFor(app, spec, MongoImage, ""),
+						ImagePullPolicy: pullPolicyFor(spec),
+						Command:         []string{"sh", "-c", script},
+						Env: []corev1.EnvVar{
+							secretKeyEnv("ROOT_USERNAME", CredentialsSecretName(name), RootUsernameKey, false),
+							secretKeyEnv("ROOT_PASSWORD", CredentialsSecretName(name), RootPasswordKey, true),
+							secretKeyEnv("PENDING_ROOT_PASSWORD", CredentialsSecretName(name), PendingRootPasswordKey, false),
+							secretKeyEnv("PENDING_USERNAME", CredentialsSecretName(name), PendingUsernameKey, false),
+							secretKeyEnv("PENDING_PASSWORD", CredentialsSecretName(name), PendingPasswordKey, false),
+						},
+					}},
+				},
+			},
+		},
+	}
+	applyPlacement(&job.Spec.Template.Spec, placementFor(app, name, TierData))
+	return jobs.From(job)
+}
+
+// CredentialsCleanupJob returns the Job dropping the previous services' user of a mongodb
+// instance, once the services no longer use it. It does nothing when the user is already gone
+func CredentialsCleanupJob(app *examplev1beta1.HotelReservationApp, name string, rotation int64) resources.Reconcileable {
+	spec := componentSpec(app, name)
+
+	drop := `var admin = db.getSiblingDB("admin");
+if (admin.getUser("'"$PREVIOUS_USERNAME"'") !== null) { admin.dropUser("'"$PREVIOUS_USERNAME"'") }`
+	script := fmt.Sprintf(`shell=mongosh; command -v mongosh >/dev/null || shell=mongo
+$shell --quiet --host '%s' --username "$ROOT_USERNAME" --password "$ROOT_PASSWORD" --authenticationDatabase admin --eval '%s'`,
+		shellHost(app, name), drop)
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: CredentialsCleanupJobName(name, rotation),
+			Labels: map[string]string{
+				"io.kompose.service": CredentialsCleanupJobName(name, rotation),
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: pointer.Int32Ptr(6),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"io.kompose.service": CredentialsCleanupJobName(name, rotation),
+					},
+				},
+				Spec: corev1.PodSpec{
+					ImagePullSecrets: app.Spec.ImagePullSecrets,
+					RestartPolicy:    corev1.RestartPolicyOnFailure,
+					Containers: []corev1.Container{{
+						Name:            "credentials-cleanup",
+						Image:           imageFor(app, spec, MongoImage, ""),
+						ImagePullPolicy: pullPolicyFor(spec),
+						Command:         []string{"sh", "-c", script},
+						Env: []corev1.EnvVar{
+							secretKeyEnv("ROOT_USERNAME", CredentialsSecretName(name), RootUsernameKey, false),
+							secretKeyEnv("ROOT_PASSWORD", CredentialsSecretName(name), RootPasswordKey, false),
+							secretKeyEnv("PREVIOUS_USERNAME", CredentialsSecretName(name), PreviousUsernameKey, false),
+						},
+					}},
+				},
+			},
+		},
+	}
+	applyPlacement(&job.Spec.Template.Spec, placementFor(app, name, TierData))
+	return jobs.From(job)
+}
+
+// secretKeyEnv returns an environment variable set to a key of a Secret
+func secretKeyEnv(env string, secretName string, key string, optional bool) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: env,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+				Key:                  key,
+				Optional:             pointer.BoolPtr(optional),
+			},
+		},
+	}
+}
+
+// enforceAuth makes mongod require authentication once the users of the instance exist. A
+// replica set member stops accepting unauthenticated connections, a standalone server enables
+// access control
+func enforceAuth(container *corev1.Container) {
+	args := []string{}
+	for _, arg := range container.Args {
+		if arg != "--transitionToAuth" {
+			args = append(args, arg)
+		}
+	}
+	if len(args) == len(container.Args) {
+		args = append(args, "--auth")
+	}
+	container.Args = args
+}
+
+// withCredentials adds the services' credentials of a mongodb instance to its address, which
+// becomes a connection string authenticating against the admin database
+func withCredentials(address string, credentials Credentials) string {
+	userinfo := url.UserPassword(credentials.Username, credentials.Password).String()
+	separator := "/?"
+	if strings.Contains(address, "?") {
+		separator = "&"
+	}
+	return userinfo + "@" + address + separator + "authSource=admin"
+}
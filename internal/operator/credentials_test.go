@@ -0,0 +1,108 @@
+package operator
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("Credentials", func() {
+	It("creates the users with pending passwords, then promotes them", func() {
+		data, err := NewCredentialsData()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(data).To(HaveKeyWithValue(RootUsernameKey, []byte("root")))
+		Expect(data).To(HaveKeyWithValue(PendingUsernameKey, []byte("hotelreservation")))
+		Expect(data[PendingPasswordKey]).To(HaveLen(32))
+		_, ok := CredentialsFromData(data)
+		Expect(ok).To(BeFalse())
+
+		pending := string(data[PendingPasswordKey])
+		PromoteCredentialsData(data)
+		Expect(data).NotTo(HaveKey(PendingPasswordKey))
+		Expect(data).NotTo(HaveKey(PendingRootPasswordKey))
+		Expect(data).NotTo(HaveKey(PendingUsernameKey))
+		Expect(data).NotTo(HaveKey(PreviousUsernameKey))
+		credentials, ok := CredentialsFromData(data)
+		Expect(ok).To(BeTrue())
+		Expect(credentials.Username).To(Equal("hotelreservation"))
+		Expect(credentials.Password).To(Equal(pending))
+	})
+
+	It("keeps the current user active while a rotation is pending", func() {
+		data, err := NewCredentialsData()
+		Expect(err).NotTo(HaveOccurred())
+		PromoteCredentialsData(data)
+		current, _ := CredentialsFromData(data)
+
+		Expect(RotateCredentialsData(data)).To(Succeed())
+		Expect(data[PendingPasswordKey]).NotTo(Equal([]byte(current.Password)))
+		Expect(data).To(HaveKeyWithValue(PendingUsernameKey, []byte("hotelreservation-rotated")))
+		active, ok := CredentialsFromData(data)
+		Expect(ok).To(BeTrue())
+		Expect(active).To(Equal(current))
+	})
+
+	It("keeps the replaced user as the previous one until it is dropped", func() {
+		data, err := NewCredentialsData()
+		Expect(err).NotTo(HaveOccurred())
+		PromoteCredentialsData(data)
+		Expect(RotateCredentialsData(data)).To(Succeed())
+
+		PromoteCredentialsData(data)
+		Expect(data).To(HaveKeyWithValue(UsernameKey, []byte("hotelreservation-rotated")))
+		Expect(data).To(HaveKeyWithValue(PreviousUsernameKey, []byte("hotelreservation")))
+
+		ForgetPreviousCredentialsData(data)
+		Expect(data).NotTo(HaveKey(PreviousUsernameKey))
+		Expect(RotateCredentialsData(data)).To(Succeed())
+		Expect(data).To(HaveKeyWithValue(PendingUsernameKey, []byte("hotelreservation")))
+	})
+
+	It("creates the pending user without touching the current one", func() {
+		job := CredentialsJob(newApp(nil), "mongodb-geo", 1).GetResource().(*batchv1.Job)
+		Expect(job.Name).To(Equal("mongodb-geo-credentials-1"))
+		Expect(envNames(job.Spec.Template.Spec.Containers[0].Env)).To(Equal([]string{
+			"ROOT_USERNAME", "ROOT_PASSWORD", "PENDING_ROOT_PASSWORD", "PENDING_USERNAME", "PENDING_PASSWORD",
+		}))
+	})
+
+	It("drops the previous user as root", func() {
+		job := CredentialsCleanupJob(newApp(nil), "mongodb-geo", 1).GetResource().(*batchv1.Job)
+		Expect(job.Name).To(Equal("mongodb-geo-credentials-1-cleanup"))
+		container := job.Spec.Template.Spec.Containers[0]
+		Expect(envNames(container.Env)).To(Equal([]string{"ROOT_USERNAME", "ROOT_PASSWORD", "PREVIOUS_USERNAME"}))
+		Expect(container.Command[2]).To(ContainSubstring(`dropUser("'"$PREVIOUS_USERNAME"'")`))
+	})
+
+	DescribeTable("enforceAuth",
+		func(args []string, expected []string) {
+			container := corev1.Container{Args: args}
+			enforceAuth(&container)
+			Expect(container.Args).To(Equal(expected))
+		},
+		Entry("enables access control of a standalone server", []string{}, []string{"--auth"}),
+		Entry("stops a replica set member accepting unauthenticated connections",
+			[]string{"--replSet", "mongodb-geo", "--transitionToAuth"}, []string{"--replSet", "mongodb-geo"}),
+	)
+
+	DescribeTable("withCredentials",
+		func(address string, expected string) {
+			Expect(withCredentials(address, Credentials{Username: "geo", Password: "p@ss"})).To(Equal(expected))
+		},
+		Entry("authenticates against the admin database", "mongodb-geo.hotel.svc:27017",
+			"geo:p%40ss@mongodb-geo.hotel.svc:27017/?authSource=admin"),
+		Entry("keeps the options of a replica set", "mongodb-geo-0.mongodb-geo-headless.hotel.svc:27017/?replicaSet=mongodb-geo",
+			"geo:p%40ss@mongodb-geo-0.mongodb-geo-headless.hotel.svc:27017/?replicaSet=mongodb-geo&authSource=admin"),
+	)
+})
+
+// envNames returns the names of the given environment variables
+func envNames(env []corev1.EnvVar) []string {
+	names := []string{}
+	for _, variable := range env {
+		names = append(names, variable.Name)
+	}
+	return names
+}
@@ -0,0 +1,80 @@
+package operator
+
+import (
+	examplev1beta1 "github.com/Youngpig1998/hotelreservation-operator/api/v1beta1"
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/resources"
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/resources/poddisruptionbudgets"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// PodDisruptionBudgets returns the PodDisruptionBudget of every component run for the app, keyed
+// by the name of its workload. The budget of a component running a single replica is nil, a
+// budget would either block node drains or not protect anything
+func PodDisruptionBudgets(app *examplev1beta1.HotelReservationApp) map[string]resources.Reconcileable {
+	budgets := map[string]resources.Reconcileable{}
+	for _, name := range []string{"consul", "jaeger"} {
+		budgets[name] = podDisruptionBudget(app, name, *replicasFor(componentSpec(app, name)) > 1)
+	}
+	for _, component := range EnabledComponents(app) {
+		if component.NeedsCache {
+			name := component.CacheName()
+			budgets[name] = podDisruptionBudget(app, name, memcachedReplicas(app, name) > 1)
+		}
+		if component.NeedsDB {
+			name := component.DBName()
+			replicas := *replicasFor(componentSpec(app, name))
+			if ReplicaSetMode(app) {
+				replicas = replicaSetMembers(app, name)
+			}
+			budgets[name] = podDisruptionBudget(app, name, replicas > 1)
+		}
+		spec := componentSpec(app, component.Name)
+		multiReplica := *replicasFor(spec) > 1
+		if spec.Autoscaling != nil {
+			multiReplica = spec.Autoscaling.MaxReplicas > 1
+		}
+		budgets[component.Name] = podDisruptionBudget(app, component.Name, multiReplica)
+	}
+	return budgets
+}
+
+// podDisruptionBudget returns the PodDisruptionBudget of the pods of a component, following the
+// budget of its component spec or else the one of the app, and allowing one unavailable pod
+// when neither sets one. The returned resource is nil for a single replica so that an existing
+// one is pruned
+func podDisruptionBudget(app *examplev1beta1.HotelReservationApp, name string, multiReplica bool) resources.Reconcileable {
+	if !multiReplica {
+		return poddisruptionbudgets.From(nil)
+	}
+
+	budget := componentSpec(app, name).DisruptionBudget
+	if budget == nil {
+		budget = app.Spec.DisruptionBudget
+	}
+	maxUnavailable := intstr.FromInt(1)
+	spec := policyv1.PodDisruptionBudgetSpec{
+		MaxUnavailable: &maxUnavailable,
+		Selector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{
+				"io.kompose.service": name,
+			},
+		},
+	}
+	if budget != nil && (budget.MinAvailable != nil || budget.MaxUnavailable != nil) {
+		spec.MinAvailable = budget.MinAvailable
+		spec.MaxUnavailable = budget.MaxUnavailable
+	}
+
+	pdb := &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				"io.kompose.service": name,
+			},
+		},
+		Spec: spec,
+	}
+	return poddisruptionbudgets.From(pdb)
+}
@@ -0,0 +1,91 @@
+package operator
+
+import (
+	examplev1beta1 "github.com/Youngpig1998/hotelreservation-operator/api/v1beta1"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/pointer"
+)
+
+// budgetedComponents returns the names of the components PodDisruptionBudgets renders a budget for
+func budgetedComponents(app *examplev1beta1.HotelReservationApp) []string {
+	names := []string{}
+	for name, budget := range PodDisruptionBudgets(app) {
+		if !budget.ResourceIsNil() {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// budgetSpec returns the spec of the budget PodDisruptionBudgets renders for a component
+func budgetSpec(app *examplev1beta1.HotelReservationApp, name string) policyv1.PodDisruptionBudgetSpec {
+	budget := PodDisruptionBudgets(app)[name]
+	Expect(budget).NotTo(BeNil())
+	Expect(budget.ResourceIsNil()).To(BeFalse())
+	return budget.GetResource().(*policyv1.PodDisruptionBudget).Spec
+}
+
+var _ = Describe("Disruption budgets", func() {
+	var (
+		one = intstr.FromInt(1)
+		two = intstr.FromInt(2)
+	)
+
+	DescribeTable("PodDisruptionBudgets",
+		func(components map[string]examplev1beta1.ComponentSpec, mongodb *examplev1beta1.MongoDBSpec, memcached *examplev1beta1.MemcachedSpec, expected []string) {
+			app := newApp(components)
+			app.Spec.MongoDB = mongodb
+			app.Spec.Memcached = memcached
+			Expect(budgetedComponents(app)).To(ConsistOf(expected))
+		},
+		Entry("renders none for single-replica components", nil, nil, nil, []string{}),
+		Entry("renders one for the components with several replicas",
+			map[string]examplev1beta1.ComponentSpec{"consul": {Replicas: pointer.Int32Ptr(3)}, "geo": {Replicas: pointer.Int32Ptr(2)}, "user": {Replicas: pointer.Int32Ptr(1)}},
+			nil, nil, []string{"consul", "geo"}),
+		Entry("renders one for the services that can be autoscaled to several replicas",
+			map[string]examplev1beta1.ComponentSpec{
+				"search":   {Autoscaling: &examplev1beta1.AutoscalingSpec{MaxReplicas: 4}},
+				"frontend": {Replicas: pointer.Int32Ptr(3), Autoscaling: &examplev1beta1.AutoscalingSpec{MaxReplicas: 1}},
+			}, nil, nil, []string{"search"}),
+		Entry("renders one for the memcached instances with several replicas", nil, nil,
+			&examplev1beta1.MemcachedSpec{Replicas: pointer.Int32Ptr(2)}, []string{"memcached-reservation", "memcached-rate", "memcached-profile"}),
+		Entry("renders one for the mongodb replica sets",
+			map[string]examplev1beta1.ComponentSpec{"mongodb-rate": {Replicas: pointer.Int32Ptr(1)}},
+			&examplev1beta1.MongoDBSpec{ReplicaSet: &examplev1beta1.MongoDBReplicaSetSpec{}}, nil,
+			[]string{"mongodb-reservation", "mongodb-profile", "mongodb-geo", "mongodb-recommendation", "mongodb-user"}),
+		Entry("renders none for the disabled services",
+			map[string]examplev1beta1.ComponentSpec{"geo": {Enabled: pointer.BoolPtr(false), Replicas: pointer.Int32Ptr(2)}},
+			nil, nil, []string{}),
+	)
+
+	It("allows one unavailable pod by default", func() {
+		app := newApp(map[string]examplev1beta1.ComponentSpec{"geo": {Replicas: pointer.Int32Ptr(2)}})
+		spec := budgetSpec(app, "geo")
+		Expect(spec.MaxUnavailable).To(Equal(&one))
+		Expect(spec.MinAvailable).To(BeNil())
+		Expect(spec.Selector.MatchLabels).To(Equal(map[string]string{"io.kompose.service": "geo"}))
+	})
+
+	It("follows the budget of the app", func() {
+		app := newApp(map[string]examplev1beta1.ComponentSpec{"geo": {Replicas: pointer.Int32Ptr(3)}})
+		app.Spec.DisruptionBudget = &examplev1beta1.DisruptionBudgetSpec{MinAvailable: &two}
+		spec := budgetSpec(app, "geo")
+		Expect(spec.MinAvailable).To(Equal(&two))
+		Expect(spec.MaxUnavailable).To(BeNil())
+	})
+
+	It("prefers the budget of the component over the one of the app", func() {
+		app := newApp(map[string]examplev1beta1.ComponentSpec{"geo": {
+			Replicas:         pointer.Int32Ptr(3),
+			DisruptionBudget: &examplev1beta1.DisruptionBudgetSpec{MaxUnavailable: &two},
+		}})
+		app.Spec.DisruptionBudget = &examplev1beta1.DisruptionBudgetSpec{MinAvailable: &two}
+		spec := budgetSpec(app, "geo")
+		Expect(spec.MaxUnavailable).To(Equal(&two))
+		Expect(spec.MinAvailable).To(BeNil())
+	})
+})
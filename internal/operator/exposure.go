@@ -0,0 +1,108 @@
+package operator
+
+import (
+	examplev1beta1 "github.com/Youngpig1998/hotelreservation-operator/api/v1beta1"
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/resources"
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/resources/ingresses"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TierFrontend is the tier of the frontend Service, which is exposed on its own
+const TierFrontend = "frontend"
+
+// exposureFor returns the exposure of the Services of a tier. The data tier defaults to
+// NodePort in NodeIP mode so that the services can reach it through the data node
+func exposureFor(app *examplev1beta1.HotelReservationApp, tier string) examplev1beta1.ServiceExposure {
+	exposure := examplev1beta1.ServiceExposure{}
+	if app.Spec.Exposure != nil {
+		switch {
+		case tier == TierData && app.Spec.Exposure.Data != nil:
+			exposure = *app.Spec.Exposure.Data.DeepCopy()
+		case tier == TierLogic && app.Spec.Exposure.Logic != nil:
+			exposure = *app.Spec.Exposure.Logic.DeepCopy()
+		case tier == TierFrontend && app.Spec.Exposure.Frontend != nil:
+			exposure = *app.Spec.Exposure.Frontend.ServiceExposure.DeepCopy()
+		}
+	}
+	if exposure.Type == "" {
+		exposure.Type = corev1.ServiceTypeClusterIP
+		if tier == TierData && !InCluster(app) {
+			exposure.Type = corev1.ServiceTypeNodePort
+		}
+	}
+	return exposure
+}
+
+// NodePortFor returns the node port of a Service of a tier. Zero is returned for ClusterIP
+// Services and lets Kubernetes allocate the port of other Services that have none pinned
+func NodePortFor(app *examplev1beta1.HotelReservationApp, serviceName string, tier string) int32 {
+	exposure := exposureFor(app, tier)
+	if exposure.Type == corev1.ServiceTypeClusterIP {
+		return 0
+	}
+	if nodePort, ok := exposure.NodePorts[serviceName]; ok {
+		return nodePort
+	}
+	if !InCluster(app) {
+		return examplev1beta1.LegacyNodePorts[serviceName]
+	}
+	return 0
+}
+
+// IngressForFrontend returns the Ingress routing external traffic to the frontend Service.
+// The returned resource is nil when no Ingress is requested so that an existing one is pruned
+func IngressForFrontend(app *examplev1beta1.HotelReservationApp) resources.Reconcileable {
+	if app.Spec.Exposure == nil || app.Spec.Exposure.Frontend == nil || app.Spec.Exposure.Frontend.Ingress == nil {
+		return ingresses.From(nil)
+	}
+	spec := app.Spec.Exposure.Frontend.Ingress
+
+	path := spec.Path
+	if path == "" {
+		path = "/"
+	}
+	pathType := networkingv1.PathTypePrefix
+
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "frontend",
+			Labels: map[string]string{
+				"io.kompose.service": "frontend",
+			},
+			Annotations: spec.Annotations,
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: spec.IngressClassName,
+			Rules: []networkingv1.IngressRule{{
+				Host: spec.Host,
+				IngressRuleValue: networkingv1.IngressRuleValue{
+					HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{{
+							Path:     path,
+							PathType: &pathType,
+							Backend: networkingv1.IngressBackend{
+								Service: &networkingv1.IngressServiceBackend{
+									Name: "frontend",
+									Port: networkingv1.ServiceBackendPort{
+										Number: FrontendPort,
+									},
+								},
+							},
+						}},
+					},
+				},
+			}},
+		},
+	}
+	if spec.TLSSecretName != "" {
+		tls := networkingv1.IngressTLS{SecretName: spec.TLSSecretName}
+		if spec.Host != "" {
+			tls.Hosts = []string{spec.Host}
+		}
+		ingress.Spec.TLS = []networkingv1.IngressTLS{tls}
+	}
+
+	return ingresses.From(ingress)
+}
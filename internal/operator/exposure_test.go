@@ -0,0 +1,67 @@
+package operator
+
+import (
+	examplev1beta1 "github.com/Youngpig1998/hotelreservation-operator/api/v1beta1"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+var _ = Describe("Exposure", func() {
+	DescribeTable("NodePortFor",
+		func(app *examplev1beta1.HotelReservationApp, serviceName string, tier string, expected int32) {
+			Expect(NodePortFor(app, serviceName, tier)).To(Equal(expected))
+		},
+		Entry("allocates no port in InCluster mode", newApp(nil), "mongodb-geo", TierData, int32(0)),
+		Entry("defaults the data tier to the legacy node ports in NodeIP mode", nodeIPApp(nil), "mongodb-user", TierData, int32(30006)),
+		Entry("allocates no port for ClusterIP data Services in NodeIP mode",
+			nodeIPApp(&examplev1beta1.ExposureSpec{Data: &examplev1beta1.ServiceExposure{Type: corev1.ServiceTypeClusterIP}}),
+			"mongodb-geo", TierData, int32(0)),
+		Entry("leaves the logic tier to Kubernetes in NodeIP mode",
+			nodeIPApp(&examplev1beta1.ExposureSpec{Logic: &examplev1beta1.ServiceExposure{Type: corev1.ServiceTypeNodePort}}),
+			"geo", TierLogic, int32(0)),
+		Entry("uses the pinned port of the frontend",
+			nodeIPApp(&examplev1beta1.ExposureSpec{Frontend: &examplev1beta1.FrontendExposure{ServiceExposure: examplev1beta1.ServiceExposure{
+				Type:      corev1.ServiceTypeNodePort,
+				NodePorts: map[string]int32{"frontend": 30080},
+			}}}),
+			"frontend", TierFrontend, int32(30080)),
+	)
+
+	DescribeTable("Service type of a tier",
+		func(app *examplev1beta1.HotelReservationApp, serviceName string, tier string, expected corev1.ServiceType) {
+			service := Service(app, serviceName, tier, 8083, 8083).GetResource().(*corev1.Service)
+			Expect(service.Spec.Type).To(Equal(expected))
+		},
+		Entry("defaults to ClusterIP", newApp(nil), "mongodb-geo", TierData, corev1.ServiceTypeClusterIP),
+		Entry("defaults the data tier to NodePort in NodeIP mode", nodeIPApp(nil), "mongodb-geo", TierData, corev1.ServiceTypeNodePort),
+		Entry("keeps the logic tier on ClusterIP in NodeIP mode", nodeIPApp(nil), "geo", TierLogic, corev1.ServiceTypeClusterIP),
+		Entry("follows the frontend exposure",
+			nodeIPApp(&examplev1beta1.ExposureSpec{Frontend: &examplev1beta1.FrontendExposure{ServiceExposure: examplev1beta1.ServiceExposure{
+				Type: corev1.ServiceTypeLoadBalancer,
+			}}}),
+			"frontend", TierFrontend, corev1.ServiceTypeLoadBalancer),
+	)
+
+	It("creates no Ingress unless requested", func() {
+		Expect(IngressForFrontend(newApp(nil)).ResourceIsNil()).To(BeTrue())
+	})
+
+	It("routes the Ingress to the frontend Service", func() {
+		app := newApp(nil)
+		app.Spec.Exposure = &examplev1beta1.ExposureSpec{Frontend: &examplev1beta1.FrontendExposure{
+			Ingress: &examplev1beta1.IngressSpec{Host: "hotel.example.com", TLSSecretName: "hotel-tls"},
+		}}
+
+		ingress := IngressForFrontend(app).GetResource().(*networkingv1.Ingress)
+		Expect(ingress.Spec.Rules).To(HaveLen(1))
+		Expect(ingress.Spec.Rules[0].Host).To(Equal("hotel.example.com"))
+		path := ingress.Spec.Rules[0].HTTP.Paths[0]
+		Expect(path.Path).To(Equal("/"))
+		Expect(path.Backend.Service.Name).To(Equal("frontend"))
+		Expect(path.Backend.Service.Port.Number).To(Equal(int32(5000)))
+		Expect(ingress.Spec.TLS).To(Equal([]networkingv1.IngressTLS{{Hosts: []string{"hotel.example.com"}, SecretName: "hotel-tls"}}))
+	})
+})
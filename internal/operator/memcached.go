@@ -0,0 +1,160 @@
+package operator
+
+import (
+	"fmt"
+	"strings"
+
+	examplev1beta1 "github.com/Youngpig1998/hotelreservation-operator/api/v1beta1"
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/resources"
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/resources/services"
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/resources/statefulsets"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+// memcachedPort is the port memcached listens on
+const memcachedPort = 11211
+
+// memcachedReplicas returns the number of replicas of a memcached instance, the replicas of its
+// component override the ones of the memcached spec
+func memcachedReplicas(app *examplev1beta1.HotelReservationApp, name string) int32 {
+	if replicas := componentSpec(app, name).Replicas; replicas != nil {
+		return *replicas
+	}
+	if app.Spec.Memcached != nil && app.Spec.Memcached.Replicas != nil {
+		return *app.Spec.Memcached.Replicas
+	}
+	return 1
+}
+
+// CacheKind returns the kind of the workload of a memcached instance. An instance with several
+// replicas runs as a StatefulSet so that each replica has a stable address the services can
+// spread the keys over, a Service load balancing across them would scatter the keys
+func CacheKind(app *examplev1beta1.HotelReservationApp, name string) string {
+	if memcachedReplicas(app, name) > 1 {
+		return KindStatefulSet
+	}
+	return KindDeployment
+}
+
+// cacheAddresses returns the addresses the services reach a memcached instance on, the
+// comma-separated addresses of its replicas when it runs as a StatefulSet
+func cacheAddresses(app *examplev1beta1.HotelReservationApp, name string) string {
+	if CacheKind(app, name) != KindStatefulSet {
+		return addressOf(app, name, memcachedPort, app.Spec.DataNodeIp, NodePortFor(app, name, TierData))
+	}
+	addresses := []string{}
+	for i := int32(0); i < memcachedReplicas(app, name); i++ {
+		addresses = append(addresses, fmt.Sprintf("%s-%d.%s.%s.svc:%d", name, i, HeadlessServiceName(name), app.Namespace, memcachedPort))
+	}
+	return strings.Join(addresses, ",")
+}
+
+// memcachedArgs renders the memcached spec into the arguments of memcached
+func memcachedArgs(app *examplev1beta1.HotelReservationApp) []string {
+	args := []string{}
+	spec := app.Spec.Memcached
+	if spec == nil {
+		return args
+	}
+	if spec.MemoryMB != nil {
+		args = append(args, "-m", fmt.Sprint(*spec.MemoryMB))
+	}
+	if spec.Threads != nil {
+		args = append(args, "-t", fmt.Sprint(*spec.Threads))
+	}
+	if spec.MaxConnections != nil {
+		args = append(args, "-c", fmt.Sprint(*spec.MaxConnections))
+	}
+	if spec.MaxItemSize != "" {
+		args = append(args, "-I", spec.MaxItemSize)
+	}
+	return args
+}
+
+// memcachedPodTemplate returns the pod template of a memcached instance
+func memcachedPodTemplate(app *examplev1beta1.HotelReservationApp, name string) corev1.PodTemplateSpec {
+	spec := componentSpec(app, name)
+
+	template := corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{
+				"io.kompose.service": name,
+			},
+		},
+		Spec: corev1.PodSpec{
+			ImagePullSecrets: app.Spec.ImagePullSecrets,
+			Containers: []corev1.Container{{
+				Image:           imageFor(app, spec, MemcachedImage, ""),
+				ImagePullPolicy: pullPolicyFor(spec),
+				Name:            "hotelreservation-" + name,
+				Args:            memcachedArgs(app),
+				Ports: []corev1.ContainerPort{{
+					ContainerPort: memcachedPort,
+				}},
+			}},
+			RestartPolicy: corev1.RestartPolicyAlways,
+		},
+	}
+	customiseContainer(&template.Spec.Containers[0], spec, memcachedResources)
+	applyProbes(&template.Spec.Containers[0], spec, tcpProbe(memcachedPort))
+	applyPlacement(&template.Spec, placementFor(app, name, TierData))
+	return template
+}
+
+// StatefulSetForMem returns the StatefulSet of the memcached instance of a service. The returned
+// resource is nil when the instance has a single replica, which runs as a Deployment instead
+func StatefulSetForMem(servicesName string, app *examplev1beta1.HotelReservationApp) resources.Reconcileable {
+	name := "memcached-" + servicesName
+	if CacheKind(app, name) != KindStatefulSet {
+		return statefulsets.From(nil)
+	}
+
+	statefulSet := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				"io.kompose.service": name,
+			},
+		},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas:    pointer.Int32Ptr(memcachedReplicas(app, name)),
+			ServiceName: HeadlessServiceName(name),
+			// The replicas do not depend on each other
+			PodManagementPolicy: appsv1.ParallelPodManagement,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"io.kompose.service": name,
+				},
+			},
+			Template: memcachedPodTemplate(app, name),
+		},
+	}
+	return statefulsets.From(statefulSet)
+}
+
+// CacheHeadlessService returns the headless Service giving each replica of a memcached instance
+// its own DNS name. The returned resource is nil when the instance has a single replica
+func CacheHeadlessService(app *examplev1beta1.HotelReservationApp, name string) resources.Reconcileable {
+	if CacheKind(app, name) != KindStatefulSet {
+		return services.From(nil)
+	}
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: HeadlessServiceName(name),
+			Labels: map[string]string{
+				"io.kompose.service": name,
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			ClusterIP: corev1.ClusterIPNone,
+			Ports:     []corev1.ServicePort{servicePort("memcached", corev1.ProtocolTCP, memcachedPort, memcachedPort)},
+			Selector: map[string]string{
+				"io.kompose.service": name,
+			},
+		},
+	}
+	return services.From(service)
+}
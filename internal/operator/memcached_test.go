@@ -0,0 +1,66 @@
+package operator
+
+import (
+	examplev1beta1 "github.com/Youngpig1998/hotelreservation-operator/api/v1beta1"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/pointer"
+)
+
+var _ = Describe("Memcached", func() {
+	DescribeTable("memcachedArgs",
+		func(spec *examplev1beta1.MemcachedSpec, expected []string) {
+			app := newApp(nil)
+			app.Spec.Memcached = spec
+			Expect(memcachedArgs(app)).To(Equal(expected))
+		},
+		Entry("passes no arguments without a spec", nil, []string{}),
+		Entry("passes no arguments for an empty spec", &examplev1beta1.MemcachedSpec{}, []string{}),
+		Entry("passes the memory limit", &examplev1beta1.MemcachedSpec{MemoryMB: pointer.Int32Ptr(128)}, []string{"-m", "128"}),
+		Entry("passes every setting in order",
+			&examplev1beta1.MemcachedSpec{MemoryMB: pointer.Int32Ptr(256), Threads: pointer.Int32Ptr(4), MaxConnections: pointer.Int32Ptr(2048), MaxItemSize: "2m"},
+			[]string{"-m", "256", "-t", "4", "-c", "2048", "-I", "2m"}),
+		Entry("leaves out the replicas", &examplev1beta1.MemcachedSpec{Replicas: pointer.Int32Ptr(3), MaxItemSize: "1m"}, []string{"-I", "1m"}),
+	)
+
+	DescribeTable("workload of an instance",
+		func(memcached *examplev1beta1.MemcachedSpec, components map[string]examplev1beta1.ComponentSpec, kind string, addresses string) {
+			app := newApp(components)
+			app.Spec.Memcached = memcached
+			Expect(CacheKind(app, "memcached-rate")).To(Equal(kind))
+			Expect(cacheAddresses(app, "memcached-rate")).To(Equal(addresses))
+			Expect(DeploymentForMem("rate", app).ResourceIsNil()).To(Equal(kind != KindDeployment))
+			Expect(StatefulSetForMem("rate", app).ResourceIsNil()).To(Equal(kind != KindStatefulSet))
+			Expect(CacheHeadlessService(app, "memcached-rate").ResourceIsNil()).To(Equal(kind != KindStatefulSet))
+		},
+		Entry("runs a single replica as a Deployment by default", nil, nil, KindDeployment, "memcached-rate.hotel.svc:11211"),
+		Entry("runs a single replica as a Deployment",
+			&examplev1beta1.MemcachedSpec{Replicas: pointer.Int32Ptr(1)}, nil, KindDeployment, "memcached-rate.hotel.svc:11211"),
+		Entry("runs several replicas as a StatefulSet addressed per replica",
+			&examplev1beta1.MemcachedSpec{Replicas: pointer.Int32Ptr(2)}, nil, KindStatefulSet,
+			"memcached-rate-0.memcached-rate-headless.hotel.svc:11211,memcached-rate-1.memcached-rate-headless.hotel.svc:11211"),
+		Entry("prefers the replicas of the instance",
+			&examplev1beta1.MemcachedSpec{Replicas: pointer.Int32Ptr(3)},
+			map[string]examplev1beta1.ComponentSpec{"memcached-rate": {Replicas: pointer.Int32Ptr(1)}}, KindDeployment, "memcached-rate.hotel.svc:11211"),
+	)
+
+	It("renders the StatefulSet and headless Service of an instance with several replicas", func() {
+		app := newApp(map[string]examplev1beta1.ComponentSpec{"memcached-rate": {Replicas: pointer.Int32Ptr(3)}})
+		app.Spec.Memcached = &examplev1beta1.MemcachedSpec{MemoryMB: pointer.Int32Ptr(64)}
+
+		statefulSet := StatefulSetForMem("rate", app).GetResource().(*appsv1.StatefulSet)
+		Expect(statefulSet.Name).To(Equal("memcached-rate"))
+		Expect(statefulSet.Spec.Replicas).To(Equal(pointer.Int32Ptr(3)))
+		Expect(statefulSet.Spec.ServiceName).To(Equal("memcached-rate-headless"))
+		Expect(statefulSet.Spec.PodManagementPolicy).To(Equal(appsv1.ParallelPodManagement))
+		Expect(statefulSet.Spec.Template.Spec.Containers[0].Args).To(Equal([]string{"-m", "64"}))
+
+		service := CacheHeadlessService(app, "memcached-rate").GetResource().(*corev1.Service)
+		Expect(service.Name).To(Equal("memcached-rate-headless"))
+		Expect(service.Spec.ClusterIP).To(Equal(corev1.ClusterIPNone))
+		Expect(service.Spec.Selector).To(Equal(statefulSet.Spec.Selector.MatchLabels))
+	})
+})
@@ -0,0 +1,243 @@
+package operator
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	examplev1beta1 "github.com/Youngpig1998/hotelreservation-operator/api/v1beta1"
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/resources"
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/resources/jobs"
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/resources/secrets"
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/resources/services"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+const (
+	// KeyfileSecretName is the name of the Secret holding the keyfile the members of the mongodb
+	// replica sets authenticate each other with
+	KeyfileSecretName = "hotelreservation-mongodb-keyfile"
+	// KeyfileKey is the key of the keyfile in KeyfileSecretName
+	KeyfileKey = "keyfile"
+	// defaultReplicaSetMembers is the number of members of a replica set when the spec sets none
+	defaultReplicaSetMembers = 3
+	// keyfileMountPath is the directory the keyfile is copied to with the permissions mongod expects
+	keyfileMountPath = "/etc/mongodb-keyfile"
+	// mongoUID is the user the mongo image runs mongod as
+	mongoUID = 999
+)
+
+// ReplicaSetMode returns whether the mongodb instances of the app run as replica sets
+func ReplicaSetMode(app *examplev1beta1.HotelReservationApp) bool {
+	return app.Spec.MongoDB != nil && app.Spec.MongoDB.ReplicaSet != nil
+}
+
+// replicaSetMembers returns the number of members of the replica set of a mongodb instance
+func replicaSetMembers(app *examplev1beta1.HotelReservationApp, name string) int32 {
+	if replicas := componentSpec(app, name).Replicas; replicas != nil {
+		return *replicas
+	}
+	if members := app.Spec.MongoDB.ReplicaSet.Members; members > 0 {
+		return members
+	}
+	return defaultReplicaSetMembers
+}
+
+// HeadlessServiceName returns the name of the headless Service governing the pods of a mongodb
+// replica set, which gives each member a stable DNS name
+func HeadlessServiceName(name string) string {
+	return name + "-headless"
+}
+
+// ReplicaSetInitJobName returns the name of the Job initiating the replica set of a mongodb instance
+func ReplicaSetInitJobName(name string) string {
+	return name + "-rs-init"
+}
+
+// memberAddresses returns the addresses of the members of the replica set of a mongodb instance
+func memberAddresses(app *examplev1beta1.HotelReservationApp, name string) []string {
+	members := []string{}
+	for i := int32(0); i < replicaSetMembers(app, name); i++ {
+		members = append(members, fmt.Sprintf("%s-%d.%s.%s.svc:27017", name, i, HeadlessServiceName(name), app.Namespace))
+	}
+	return members
+}
+
+// mongoAddress returns the address the services reach a mongodb instance on, the connection
+// string of its replica set in replica set mode
+func mongoAddress(app *examplev1beta1.HotelReservationApp, name string) string {
+	if ReplicaSetMode(app) {
+		return fmt.Sprintf("%s/?replicaSet=%s", strings.Join(memberAddresses(app, name), ","), name)
+	}
+	return addressOf(app, name, 27017, app.Spec.DataNodeIp, NodePortFor(app, name, TierData))
+}
+
+// shellHost returns the --host the mongo tools of a Job connect to a mongodb instance with, the
+// replica set and its members in replica set mode
+func shellHost(app *examplev1beta1.HotelReservationApp, name string) string {
+	if ReplicaSetMode(app) {
+		return name + "/" + strings.Join(memberAddresses(app, name), ",")
+	}
+	return fmt.Sprintf("%s.%s.svc:27017", name, app.Namespace)
+}
+
+// GenerateKeyfile returns a new random keyfile for the mongodb replica sets
+func GenerateKeyfile() (string, error) {
+	key := make([]byte, 756)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// KeyfileSecret returns the Secret holding the keyfile of the mongodb replica sets. The returned
+// resource is nil outside of replica set mode so that an existing one is pruned
+func KeyfileSecret(app *examplev1beta1.HotelReservationApp, keyfile string) resources.Reconcileable {
+	if !ReplicaSetMode(app) {
+		return secrets.From(nil)
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: KeyfileSecretName,
+			Labels: map[string]string{
+				"io.kompose.service": KeyfileSecretName,
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			KeyfileKey: []byte(keyfile),
+		},
+	}
+	return secrets.From(secret)
+}
+
+// HeadlessService returns the headless Service governing the pods of the replica set of a
+// mongodb instance. The returned resource is nil outside of replica set mode
+func HeadlessService(app *examplev1beta1.HotelReservationApp, name string) resources.Reconcileable {
+	if !ReplicaSetMode(app) {
+		return services.From(nil)
+	}
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: HeadlessServiceName(name),
+			Labels: map[string]string{
+				"io.kompose.service": name,
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			ClusterIP: corev1.ClusterIPNone,
+			// The members have to resolve each other before they are ready
+			PublishNotReadyAddresses: true,
+			Ports:                    []corev1.ServicePort{servicePort("mongodb", corev1.ProtocolTCP, 27017, 27017)},
+			Selector: map[string]string{
+				"io.kompose.service": name,
+			},
+		},
+	}
+	return services.From(service)
+}
+
+// ReplicaSetInitJob returns the Job initiating the replica set of a mongodb instance once its
+// members are reachable. It does nothing when the replica set is already initiated. The
+// returned resource is nil outside of replica set mode
+func ReplicaSetInitJob(app *examplev1beta1.HotelReservationApp, name string) resources.Reconcileable {
+	if !ReplicaSetMode(app) {
+		return jobs.From(nil)
+	}
+	spec := componentSpec(app, name)
+
+	members := []string{}
+	for i, address := range memberAddresses(app, name) {
+		members = append(members, fmt.Sprintf(`{_id: %d, host: "%s"}`, i, address))
+	}
+	config := fmt.Sprintf(`{_id: "%s", members: [%s]}`, name, strings.Join(members, ", "))
+	initiate := `var initiated = false;
+try { initiated = db.adminCommand({replSetGetStatus: 1}).ok === 1 } catch (e) {}
+if (!initiated) { var result = rs.initiate(` + config + `); if (result.ok !== 1) { printjson(result); quit(1) } }`
+
+	// The mongo shell was replaced by mongosh in recent images
+	script := fmt.Sprintf(`shell=mongosh; command -v mongosh >/dev/null || shell=mongo
+until $shell --quiet --host %[1]s --eval 'db.adminCommand("ping")'; do sleep 5; done
+$shell --quiet --host %[1]s --eval '%[2]s'`, memberAddresses(app, name)[0], initiate)
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: ReplicaSetInitJobName(name),
+			Labels: map[string]string{
+				"io.kompose.service": ReplicaSetInitJobName(name),
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: pointer.Int32Ptr(6),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"io.kompose.service": ReplicaSetInitJobName(name),
+					},
+				},
+				Spec: corev1.PodSpec{
+					ImagePullSecrets: app.Spec.ImagePullSecrets,
+					RestartPolicy:    corev1.RestartPolicyOnFailure,
+					Containers: []corev1.Container{{
+						Name:            "rs-init",
+						Image:           imageFor(app, spec, MongoImage, ""),
+						ImagePullPolicy: pullPolicyFor(spec),
+						Command:         []string{"sh", "-c", script},
+					}},
+				},
+			},
+		},
+	}
+	applyPlacement(&job.Spec.Template.Spec, placementFor(app, name, TierData))
+	return jobs.From(job)
+}
+
+// applyReplicaSet turns the pod template of a mongodb StatefulSet into the one of a replica set
+// member: mongod joins the replica set named after the instance and authenticates the other
+// members with the keyfile, which an init container copies with the permissions mongod
+// requires. transitionToAuth keeps accepting the services' unauthenticated connections
+func applyReplicaSet(app *examplev1beta1.HotelReservationApp, name string, podSpec *corev1.PodSpec) {
+	container := &podSpec.Containers[0]
+	container.Args = []string{
+		"--replSet", name,
+		"--bind_ip_all",
+		"--keyFile", keyfileMountPath + "/" + KeyfileKey,
+		"--transitionToAuth",
+	}
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+		Name:      "keyfile",
+		MountPath: keyfileMountPath,
+		ReadOnly:  true,
+	})
+
+	copyKeyfile := fmt.Sprintf("cp /keyfile-secret/%[1]s %[2]s/%[1]s && chmod 400 %[2]s/%[1]s && chown %[3]d:%[3]d %[2]s/%[1]s",
+		KeyfileKey, keyfileMountPath, mongoUID)
+	podSpec.InitContainers = []corev1.Container{{
+		Name:            "copy-keyfile",
+		Image:           container.Image,
+		ImagePullPolicy: container.ImagePullPolicy,
+		Command:         []string{"sh", "-c", copyKeyfile},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: "keyfile-secret", MountPath: "/keyfile-secret", ReadOnly: true},
+			{Name: "keyfile", MountPath: keyfileMountPath},
+		},
+	}}
+	podSpec.Volumes = append(podSpec.Volumes,
+		corev1.Volume{
+			Name: "keyfile-secret",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{SecretName: KeyfileSecretName},
+			},
+		},
+		corev1.Volume{
+			Name: "keyfile",
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		},
+	)
+}
@@ -0,0 +1,77 @@
+package operator
+
+import (
+	examplev1beta1 "github.com/Youngpig1998/hotelreservation-operator/api/v1beta1"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/pointer"
+)
+
+// replicaSetApp returns a HotelReservationApp running its mongodb instances as replica sets
+// of the given number of members
+func replicaSetApp(members int32, components map[string]examplev1beta1.ComponentSpec) *examplev1beta1.HotelReservationApp {
+	app := newApp(components)
+	app.Spec.MongoDB = &examplev1beta1.MongoDBSpec{ReplicaSet: &examplev1beta1.MongoDBReplicaSetSpec{Members: members}}
+	return app
+}
+
+var _ = Describe("MongoDB", func() {
+	DescribeTable("mongoAddress",
+		func(app *examplev1beta1.HotelReservationApp, expected string) {
+			Expect(mongoAddress(app, "mongodb-geo")).To(Equal(expected))
+		},
+		Entry("addresses a standalone instance through its Service", newApp(nil), "mongodb-geo.hotel.svc:27017"),
+		Entry("addresses a standalone instance through the data node in NodeIP mode", nodeIPApp(nil), "10.0.0.2:30001"),
+		Entry("lists three members of a replica set by default", replicaSetApp(0, nil),
+			"mongodb-geo-0.mongodb-geo-headless.hotel.svc:27017,mongodb-geo-1.mongodb-geo-headless.hotel.svc:27017,"+
+				"mongodb-geo-2.mongodb-geo-headless.hotel.svc:27017/?replicaSet=mongodb-geo"),
+		Entry("lists the members requested in the spec", replicaSetApp(1, nil),
+			"mongodb-geo-0.mongodb-geo-headless.hotel.svc:27017/?replicaSet=mongodb-geo"),
+		Entry("lets the replicas of the instance override the members",
+			replicaSetApp(1, map[string]examplev1beta1.ComponentSpec{"mongodb-geo": {Replicas: pointer.Int32Ptr(2)}}),
+			"mongodb-geo-0.mongodb-geo-headless.hotel.svc:27017,mongodb-geo-1.mongodb-geo-headless.hotel.svc:27017/?replicaSet=mongodb-geo"),
+	)
+
+	It("creates no replica set resources for standalone instances", func() {
+		Expect(ReplicaSetMode(newApp(nil))).To(BeFalse())
+		Expect(KeyfileSecret(newApp(nil), "key").ResourceIsNil()).To(BeTrue())
+		Expect(HeadlessService(newApp(nil), "mongodb-geo").ResourceIsNil()).To(BeTrue())
+		Expect(ReplicaSetInitJob(newApp(nil), "mongodb-geo").ResourceIsNil()).To(BeTrue())
+	})
+
+	It("stores the keyfile in a Secret", func() {
+		keyfile, err := GenerateKeyfile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(keyfile).NotTo(BeEmpty())
+
+		secret := KeyfileSecret(replicaSetApp(3, nil), keyfile).GetResource().(*corev1.Secret)
+		Expect(secret.Name).To(Equal(KeyfileSecretName))
+		Expect(secret.Data).To(HaveKeyWithValue(KeyfileKey, []byte(keyfile)))
+	})
+
+	It("governs the members with a headless Service", func() {
+		service := HeadlessService(replicaSetApp(3, nil), "mongodb-geo").GetResource().(*corev1.Service)
+		Expect(service.Name).To(Equal("mongodb-geo-headless"))
+		Expect(service.Spec.ClusterIP).To(Equal(corev1.ClusterIPNone))
+	})
+
+	It("initiates the replica set through its first member", func() {
+		job := ReplicaSetInitJob(replicaSetApp(2, nil), "mongodb-geo").GetResource().(*batchv1.Job)
+		Expect(job.Name).To(Equal("mongodb-geo-rs-init"))
+		script := job.Spec.Template.Spec.Containers[0].Command[2]
+		Expect(script).To(ContainSubstring("--host mongodb-geo-0.mongodb-geo-headless.hotel.svc:27017"))
+		Expect(script).To(ContainSubstring(`{_id: 1, host: "mongodb-geo-1.mongodb-geo-headless.hotel.svc:27017"}`))
+	})
+
+	It("turns a mongod pod into a replica set member", func() {
+		podSpec := corev1.PodSpec{Containers: []corev1.Container{{Name: "hotel-reserv-geo-mongo", Image: "mongo:4.4.6"}}}
+		applyReplicaSet(replicaSetApp(3, nil), "mongodb-geo", &podSpec)
+		Expect(podSpec.Containers[0].Args).To(ContainElements("--replSet", "mongodb-geo", "--transitionToAuth"))
+		Expect(podSpec.InitContainers).To(HaveLen(1))
+		Expect(podSpec.InitContainers[0].Image).To(Equal("mongo:4.4.6"))
+		Expect(podSpec.Volumes).To(HaveLen(2))
+	})
+})
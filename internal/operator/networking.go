@@ -0,0 +1,38 @@
+package operator
+
+import (
+	"fmt"
+
+	examplev1beta1 "github.com/Youngpig1998/hotelreservation-operator/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// InCluster returns whether the services of the app address each other through ClusterIP
+// Service DNS names rather than through node IPs
+func InCluster(app *examplev1beta1.HotelReservationApp) bool {
+	return app.Spec.NetworkMode != examplev1beta1.NetworkModeNodeIP
+}
+
+// serviceAddress returns the in-cluster DNS address of a Service of the app
+func serviceAddress(app *examplev1beta1.HotelReservationApp, serviceName string, port int32) string {
+	return fmt.Sprintf("%s.%s.svc:%d", serviceName, app.Namespace, port)
+}
+
+// addressOf returns the address the services reach a dependency on: its Service DNS name and
+// port in InCluster mode, or the IP of the node it runs on and its host or node port otherwise.
+// A dependency without a node port, such as one behind a ClusterIP Service, is reached through
+// its Service DNS name in both modes
+func addressOf(app *examplev1beta1.HotelReservationApp, serviceName string, port int32, nodeIP string, nodePort int32) string {
+	if InCluster(app) || nodePort == 0 {
+		return serviceAddress(app, serviceName, port)
+	}
+	return fmt.Sprintf("%s:%d", nodeIP, nodePort)
+}
+
+// dropHostPorts removes the host ports of a container, they are only needed when services are
+// addressed through node IPs
+func dropHostPorts(container *corev1.Container) {
+	for i := range container.Ports {
+		container.Ports[i].HostPort = 0
+	}
+}
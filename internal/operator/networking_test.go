@@ -0,0 +1,47 @@
+package operator
+
+import (
+	examplev1beta1 "github.com/Youngpig1998/hotelreservation-operator/api/v1beta1"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// nodeIPApp returns a HotelReservationApp in NodeIP mode with the given exposure
+func nodeIPApp(exposure *examplev1beta1.ExposureSpec) *examplev1beta1.HotelReservationApp {
+	app := newApp(nil)
+	app.Spec.NetworkMode = examplev1beta1.NetworkModeNodeIP
+	app.Spec.LogicNodeIp = "10.0.0.1"
+	app.Spec.DataNodeIp = "10.0.0.2"
+	app.Spec.Exposure = exposure
+	return app
+}
+
+var _ = Describe("Networking", func() {
+	It("addresses the services through their Service DNS names unless in NodeIP mode", func() {
+		app := newApp(nil)
+		Expect(InCluster(app)).To(BeTrue())
+		app.Spec.NetworkMode = examplev1beta1.NetworkModeInCluster
+		Expect(InCluster(app)).To(BeTrue())
+		app.Spec.NetworkMode = examplev1beta1.NetworkModeNodeIP
+		Expect(InCluster(app)).To(BeFalse())
+	})
+
+	It("addresses a dependency through its Service or its node", func() {
+		Expect(addressOf(newApp(nil), "mongodb-geo", 27017, "10.0.0.2", 30001)).To(Equal("mongodb-geo.hotel.svc:27017"))
+		Expect(addressOf(nodeIPApp(nil), "mongodb-geo", 27017, "10.0.0.2", 30001)).To(Equal("10.0.0.2:30001"))
+	})
+
+	It("falls back to the Service of a dependency without a node port in NodeIP mode", func() {
+		Expect(addressOf(nodeIPApp(nil), "mongodb-geo", 27017, "10.0.0.2", 0)).To(Equal("mongodb-geo.hotel.svc:27017"))
+		app := nodeIPApp(&examplev1beta1.ExposureSpec{Data: &examplev1beta1.ServiceExposure{Type: corev1.ServiceTypeClusterIP}})
+		Expect(mongoAddress(app, "mongodb-geo")).To(Equal("mongodb-geo.hotel.svc:27017"))
+		Expect(cacheAddresses(app, "memcached-rate")).To(Equal("memcached-rate.hotel.svc:11211"))
+	})
+
+	It("drops the host ports of a container", func() {
+		container := corev1.Container{Ports: []corev1.ContainerPort{{ContainerPort: 8083, HostPort: 8083}}}
+		dropHostPorts(&container)
+		Expect(container.Ports).To(Equal([]corev1.ContainerPort{{ContainerPort: 8083}}))
+	})
+})
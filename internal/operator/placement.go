@@ -0,0 +1,72 @@
+package operator
+
+import (
+	examplev1beta1 "github.com/Youngpig1998/hotelreservation-operator/api/v1beta1"
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/common"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Tiers of the application, each tier has its own placement
+const (
+	TierLogic = "logic"
+	TierData  = "data"
+)
+
+// placementFor resolves the placement of a component from its tier's placement, falling back
+// to the two-node shorthand of the spec, and then applies the component's own overrides
+func placementFor(app *examplev1beta1.HotelReservationApp, name string, tier string) examplev1beta1.Placement {
+	placement := examplev1beta1.Placement{}
+	if tierPlacement := placementForTier(app, tier); tierPlacement != nil {
+		placement = *tierPlacement.DeepCopy()
+	} else if nodeName := shorthandNodeName(app, name, tier); nodeName != "" {
+		placement.NodeSelector = map[string]string{
+			"kubernetes.io/hostname": nodeName,
+		}
+	}
+
+	override := componentSpec(app, name).Placement
+	if override == nil {
+		return placement
+	}
+	if len(override.NodeSelector) != 0 {
+		placement.NodeSelector = common.CombineStringStringMaps(placement.NodeSelector, override.NodeSelector)
+	}
+	if override.Affinity != nil {
+		placement.Affinity = override.Affinity.DeepCopy()
+	}
+	if override.Tolerations != nil {
+		placement.Tolerations = override.DeepCopy().Tolerations
+	}
+	if override.TopologySpreadConstraints != nil {
+		placement.TopologySpreadConstraints = override.DeepCopy().TopologySpreadConstraints
+	}
+	return placement
+}
+
+// placementForTier returns the placement declared in the spec for a tier, or nil if none is
+func placementForTier(app *examplev1beta1.HotelReservationApp, tier string) *examplev1beta1.Placement {
+	if app.Spec.Placement == nil {
+		return nil
+	}
+	if tier == TierData {
+		return app.Spec.Placement.Data
+	}
+	return app.Spec.Placement.Logic
+}
+
+// shorthandNodeName returns the node a component is pinned to by the logicNodeName and
+// dataNodeName fields. The search service has always run on the data node
+func shorthandNodeName(app *examplev1beta1.HotelReservationApp, name string, tier string) string {
+	if tier == TierData || name == "search" {
+		return app.Spec.DataNodeName
+	}
+	return app.Spec.LogicNodeName
+}
+
+// applyPlacement sets the scheduling constraints of a placement on a pod spec
+func applyPlacement(podSpec *corev1.PodSpec, placement examplev1beta1.Placement) {
+	podSpec.NodeSelector = placement.NodeSelector
+	podSpec.Affinity = placement.Affinity
+	podSpec.Tolerations = placement.Tolerations
+	podSpec.TopologySpreadConstraints = placement.TopologySpreadConstraints
+}
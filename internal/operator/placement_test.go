@@ -0,0 +1,54 @@
+package operator
+
+import (
+	examplev1beta1 "github.com/Youngpig1998/hotelreservation-operator/api/v1beta1"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("Placement", func() {
+	dedicated := []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpExists}}
+
+	DescribeTable("placementFor",
+		func(spec examplev1beta1.HotelReservationAppSpec, name string, tier string, expected examplev1beta1.Placement) {
+			app := newApp(nil)
+			app.Spec = spec
+			Expect(placementFor(app, name, tier)).To(Equal(expected))
+		},
+		Entry("leaves a component unconstrained by default", examplev1beta1.HotelReservationAppSpec{}, "geo", TierLogic,
+			examplev1beta1.Placement{}),
+		Entry("pins a logic service to the logic node",
+			examplev1beta1.HotelReservationAppSpec{LogicNodeName: "logic", DataNodeName: "data"}, "geo", TierLogic,
+			examplev1beta1.Placement{NodeSelector: map[string]string{"kubernetes.io/hostname": "logic"}}),
+		Entry("pins the search service to the data node",
+			examplev1beta1.HotelReservationAppSpec{LogicNodeName: "logic", DataNodeName: "data"}, "search", TierLogic,
+			examplev1beta1.Placement{NodeSelector: map[string]string{"kubernetes.io/hostname": "data"}}),
+		Entry("pins a data component to the data node",
+			examplev1beta1.HotelReservationAppSpec{LogicNodeName: "logic", DataNodeName: "data"}, "mongodb-geo", TierData,
+			examplev1beta1.Placement{NodeSelector: map[string]string{"kubernetes.io/hostname": "data"}}),
+		Entry("prefers the placement of the tier over the node names",
+			examplev1beta1.HotelReservationAppSpec{
+				DataNodeName: "data",
+				Placement:    &examplev1beta1.PlacementSpec{Data: &examplev1beta1.Placement{NodeSelector: map[string]string{"disk": "ssd"}}},
+			}, "mongodb-geo", TierData,
+			examplev1beta1.Placement{NodeSelector: map[string]string{"disk": "ssd"}}),
+		Entry("applies the overrides of the component over the placement of its tier",
+			examplev1beta1.HotelReservationAppSpec{
+				Placement: &examplev1beta1.PlacementSpec{Logic: &examplev1beta1.Placement{NodeSelector: map[string]string{"zone": "a"}}},
+				Components: map[string]examplev1beta1.ComponentSpec{
+					"geo": {Placement: &examplev1beta1.Placement{NodeSelector: map[string]string{"gpu": "true"}, Tolerations: dedicated}},
+				},
+			}, "geo", TierLogic,
+			examplev1beta1.Placement{NodeSelector: map[string]string{"zone": "a", "gpu": "true"}, Tolerations: dedicated}),
+	)
+
+	It("sets the placement on the pod spec", func() {
+		podSpec := corev1.PodSpec{}
+		applyPlacement(&podSpec, examplev1beta1.Placement{NodeSelector: map[string]string{"zone": "a"}, Tolerations: dedicated})
+		Expect(podSpec.NodeSelector).To(Equal(map[string]string{"zone": "a"}))
+		Expect(podSpec.Tolerations).To(Equal(dedicated))
+		Expect(podSpec.Affinity).To(BeNil())
+	})
+})
@@ -0,0 +1,74 @@
+package operator
+
+import (
+	examplev1beta1 "github.com/Youngpig1998/hotelreservation-operator/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// mongoPingScript pings the local mongod with whichever mongo shell the image ships, ping is
+// allowed without authentication
+const mongoPingScript = `shell=mongosh; command -v mongosh >/dev/null || shell=mongo
+$shell --quiet --eval 'db.adminCommand("ping")'`
+
+// tcpProbe checks that a port accepts connections
+func tcpProbe(port int32) corev1.ProbeHandler {
+	return corev1.ProbeHandler{
+		TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(int(port))},
+	}
+}
+
+// httpProbe checks that a GET on a path of a port succeeds
+func httpProbe(path string, port int32) corev1.ProbeHandler {
+	return corev1.ProbeHandler{
+		HTTPGet: &corev1.HTTPGetAction{Path: path, Port: intstr.FromInt(int(port)), Scheme: corev1.URISchemeHTTP},
+	}
+}
+
+// mongoProbe checks that mongod answers a ping
+func mongoProbe() corev1.ProbeHandler {
+	return corev1.ProbeHandler{
+		Exec: &corev1.ExecAction{Command: []string{"sh", "-c", mongoPingScript}},
+	}
+}
+
+// applyProbes sets the probes of a component's main container. The startup probe gives the
+// container up to five minutes to come up, after which the readiness probe takes it out of its
+// Services within 30 seconds of failing and the liveness probe restarts it within a minute. Each
+// probe set in the spec replaces the default one
+func applyProbes(container *corev1.Container, spec examplev1beta1.ComponentSpec, handler corev1.ProbeHandler) {
+	container.StartupProbe = &corev1.Probe{
+		ProbeHandler:     handler,
+		TimeoutSeconds:   5,
+		SuccessThreshold: 1,
+		PeriodSeconds:    10,
+		FailureThreshold: 30,
+	}
+	container.ReadinessProbe = &corev1.Probe{
+		ProbeHandler:     handler,
+		TimeoutSeconds:   5,
+		SuccessThreshold: 1,
+		PeriodSeconds:    10,
+		FailureThreshold: 3,
+	}
+	container.LivenessProbe = &corev1.Probe{
+		ProbeHandler:     handler,
+		TimeoutSeconds:   5,
+		SuccessThreshold: 1,
+		PeriodSeconds:    20,
+		FailureThreshold: 3,
+	}
+
+	if spec.Probes == nil {
+		return
+	}
+	if spec.Probes.Startup != nil {
+		container.StartupProbe = spec.Probes.Startup.DeepCopy()
+	}
+	if spec.Probes.Readiness != nil {
+		container.ReadinessProbe = spec.Probes.Readiness.DeepCopy()
+	}
+	if spec.Probes.Liveness != nil {
+		container.LivenessProbe = spec.Probes.Liveness.DeepCopy()
+	}
+}
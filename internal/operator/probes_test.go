@@ -0,0 +1,61 @@
+package operator
+
+import (
+	examplev1beta1 "github.com/Youngpig1998/hotelreservation-operator/api/v1beta1"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// defaultProbe returns the default probe of applyProbes with the given period and failure threshold
+func defaultProbe(handler corev1.ProbeHandler, period int32, failureThreshold int32) *corev1.Probe {
+	return &corev1.Probe{
+		ProbeHandler:     handler,
+		TimeoutSeconds:   5,
+		SuccessThreshold: 1,
+		PeriodSeconds:    period,
+		FailureThreshold: failureThreshold,
+	}
+}
+
+var _ = Describe("Probes", func() {
+	handler := tcpProbe(8083)
+	healthz := &corev1.Probe{ProbeHandler: httpProbe("/healthz", 8080), PeriodSeconds: 5}
+	ready := &corev1.Probe{ProbeHandler: httpProbe("/ready", 8080), InitialDelaySeconds: 15}
+	started := &corev1.Probe{ProbeHandler: mongoProbe(), FailureThreshold: 60}
+
+	DescribeTable("applyProbes",
+		func(probes *examplev1beta1.ProbesSpec, startup *corev1.Probe, readiness *corev1.Probe, liveness *corev1.Probe) {
+			container := corev1.Container{}
+			applyProbes(&container, examplev1beta1.ComponentSpec{Probes: probes}, handler)
+			Expect(container.StartupProbe).To(Equal(startup))
+			Expect(container.ReadinessProbe).To(Equal(readiness))
+			Expect(container.LivenessProbe).To(Equal(liveness))
+		},
+		Entry("sets the default probes without overrides", nil,
+			defaultProbe(handler, 10, 30), defaultProbe(handler, 10, 3), defaultProbe(handler, 20, 3)),
+		Entry("sets the default probes for empty overrides", &examplev1beta1.ProbesSpec{},
+			defaultProbe(handler, 10, 30), defaultProbe(handler, 10, 3), defaultProbe(handler, 20, 3)),
+		Entry("replaces only the overridden probe", &examplev1beta1.ProbesSpec{Readiness: ready},
+			defaultProbe(handler, 10, 30), ready, defaultProbe(handler, 20, 3)),
+		Entry("replaces every overridden probe", &examplev1beta1.ProbesSpec{Startup: started, Readiness: ready, Liveness: healthz},
+			started, ready, healthz),
+	)
+
+	It("copies the overrides so that the spec is left untouched", func() {
+		spec := examplev1beta1.ComponentSpec{Probes: &examplev1beta1.ProbesSpec{Liveness: healthz.DeepCopy()}}
+		container := corev1.Container{}
+		applyProbes(&container, spec, handler)
+		container.LivenessProbe.PeriodSeconds = 60
+		Expect(spec.Probes.Liveness).To(Equal(healthz))
+	})
+
+	It("probes the frontend over HTTP and the other services for connections", func() {
+		frontend := DeploymentForLogic(catalogueComponent("frontend"), newApp(nil), nil).GetResource().(*appsv1.Deployment)
+		Expect(frontend.Spec.Template.Spec.Containers[0].ReadinessProbe.HTTPGet).To(Equal(httpProbe("/", FrontendPort).HTTPGet))
+		geo := DeploymentForLogic(catalogueComponent("geo"), newApp(nil), nil).GetResource().(*appsv1.Deployment)
+		Expect(geo.Spec.Template.Spec.Containers[0].ReadinessProbe.TCPSocket).To(Equal(tcpProbe(8083).TCPSocket))
+	})
+})
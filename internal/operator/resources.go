@@ -8,18 +8,35 @@ import (
 	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/resources/statefulsets"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/utils/pointer"
-	"strconv"
 )
 
+// StorageRequest is the default size of the volume of each mongodb replica
 const (
 	StorageRequest = "1Gi"
 )
 
-func Service(serviceName string, port int32, targetPort int32, nodePort int32) resources.Reconcileable {
+// Service returns a Service exposing port of the component named serviceName, its type and
+// node port follow the exposure of the component's tier
+func Service(app *examplev1beta1.HotelReservationApp, serviceName string, tier string, port int32, targetPort int32) resources.Reconcileable {
+	return ServiceForPorts(app, serviceName, tier, []corev1.ServicePort{{
+		Protocol:   corev1.ProtocolTCP,
+		Port:       port,
+		TargetPort: intstr.FromInt(int(targetPort)),
+	}})
+}
+
+// ServiceForPorts returns a Service exposing several ports of the component named serviceName.
+// Its type follows the exposure of the component's tier, which may pin the node port of the
+// first port
+func ServiceForPorts(app *examplev1beta1.HotelReservationApp, serviceName string, tier string, ports []corev1.ServicePort) resources.Reconcileable {
+
+	exposure := exposureFor(app, tier)
+	if len(ports) > 0 {
+		ports[0].NodePort = NodePortFor(app, serviceName, tier)
+	}
 
 	service := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
@@ -27,31 +44,75 @@ func Service(serviceName string, port int32, targetPort int32, nodePort int32) r
 			Labels: map[string]string{
 				"io.kompose.service": serviceName,
 			},
+			Annotations: exposure.Annotations,
 		},
 		Spec: corev1.ServiceSpec{
-			Ports: []corev1.ServicePort{{
-				Protocol: corev1.ProtocolTCP,
-				Port:     port,
-				TargetPort: intstr.IntOrString{
-					IntVal: targetPort,
-					StrVal: strconv.Itoa(int(targetPort)),
-				},
-				NodePort: nodePort,
-			},
-			},
+			Ports: ports,
 			Selector: map[string]string{
 				"io.kompose.service": serviceName,
 			},
-			Type: "NodePort",
+			Type: exposure.Type,
 		},
 	}
 
 	return services.From(service)
 }
 
-func StatefulSet(servicesName string, app *examplev1beta1.HotelReservationApp) resources.Reconcileable {
+// ServiceForLogic returns the Service of a logic service, exposing its gRPC port or the HTTP
+// port of the frontend
+func ServiceForLogic(component Component, app *examplev1beta1.HotelReservationApp) resources.Reconcileable {
+	portName := "grpc"
+	if component.Tier == TierFrontend {
+		portName = "http"
+	}
+	return ServiceForPorts(app, component.Name, component.Tier, []corev1.ServicePort{{
+		Name:       portName,
+		Protocol:   corev1.ProtocolTCP,
+		Port:       component.Port,
+		TargetPort: intstr.FromInt(int(component.Port)),
+	}})
+}
+
+// ServiceForConsul returns the Service of consul, exposing its HTTP API first followed by its
+// server RPC, CLI RPC and DNS ports
+func ServiceForConsul(app *examplev1beta1.HotelReservationApp) resources.Reconcileable {
+	return ServiceForPorts(app, "consul", TierLogic, []corev1.ServicePort{
+		servicePort("http", corev1.ProtocolTCP, 8500, 8500),
+		servicePort("server", corev1.ProtocolTCP, 8300, 8300),
+		servicePort("cli-rpc", corev1.ProtocolTCP, 8400, 8400),
+		servicePort("dns", corev1.ProtocolUDP, 8600, 53),
+	})
+}
+
+// ServiceForJaeger returns the Service of jaeger, exposing its query UI first followed by the
+// agent, collector and admin ports
+func ServiceForJaeger(app *examplev1beta1.HotelReservationApp) resources.Reconcileable {
+	return ServiceForPorts(app, "jaeger", TierLogic, []corev1.ServicePort{
+		servicePort("query", corev1.ProtocolTCP, 16686, 16686),
+		servicePort("agent-zipkin-thrift", corev1.ProtocolUDP, 5775, 5775),
+		servicePort("agent-compact", corev1.ProtocolUDP, 6831, 6831),
+		servicePort("agent-binary", corev1.ProtocolUDP, 6832, 6832),
+		servicePort("agent-configs", corev1.ProtocolTCP, 5778, 5778),
+		servicePort("collector-tchannel", corev1.ProtocolTCP, 14267, 14267),
+		servicePort("collector-http", corev1.ProtocolTCP, 14268, 14268),
+		servicePort("admin", corev1.ProtocolTCP, 14269, 14269),
+	})
+}
+
+func servicePort(name string, protocol corev1.Protocol, port int32, targetPort int32) corev1.ServicePort {
+	return corev1.ServicePort{
+		Name:       name,
+		Protocol:   protocol,
+		Port:       port,
+		TargetPort: intstr.FromInt(int(targetPort)),
+	}
+}
+
+func StatefulSet(servicesName string, app *examplev1beta1.HotelReservationApp, credentials MongoCredentials) resources.Reconcileable {
 
 	statefulSetName := "mongodb-" + servicesName
+	spec := componentSpec(app, statefulSetName)
+	storage := storageFor(app, statefulSetName)
 
 	statefulSet := &appsv1.StatefulSet{
 		ObjectMeta: metav1.ObjectMeta{
@@ -61,7 +122,7 @@ func StatefulSet(servicesName string, app *examplev1beta1.HotelReservationApp) r
 			},
 		},
 		Spec: appsv1.StatefulSetSpec{
-			Replicas: pointer.Int32Ptr(1),
+			Replicas: replicasFor(spec),
 			Selector: &metav1.LabelSelector{
 				MatchLabels: map[string]string{
 					"io.kompose.service": statefulSetName,
@@ -75,12 +136,13 @@ func StatefulSet(servicesName string, app *examplev1beta1.HotelReservationApp) r
 					},
 				},
 				Spec: corev1.PodSpec{
-					Volumes: nil,
+					ImagePullSecrets: app.Spec.ImagePullSecrets,
+					Volumes:          volumes(servicesName, storage),
 
 					Containers: []corev1.Container{{
 						Name:            "hotelreservation-" + statefulSetName,
-						Image:           "mongo",
-						ImagePullPolicy: "IfNotPresent",
+						Image:           imageFor(app, spec, MongoImage, ""),
+						ImagePullPolicy: pullPolicyFor(spec),
 						Ports: []corev1.ContainerPort{{
 							ContainerPort: 27017,
 						}},
@@ -91,44 +153,34 @@ func StatefulSet(servicesName string, app *examplev1beta1.HotelReservationApp) r
 						},
 					},
 					},
-
-					NodeSelector: map[string]string{
-						"kubernetes.io/hostname": app.Spec.DataNodeName,
-					},
-					ServiceAccountName: "nfs-provisioner",
 				},
 			},
-			VolumeClaimTemplates: []corev1.PersistentVolumeClaim{{
-				ObjectMeta: metav1.ObjectMeta{
-					Name: servicesName,
-					Annotations: map[string]string{
-						"volume.beta.kubernetes.io/storage-class": "managed-nfs-storage",
-					},
-				},
-				Spec: corev1.PersistentVolumeClaimSpec{
-					AccessModes: []corev1.PersistentVolumeAccessMode{"ReadWriteOnce"},
-					Resources: corev1.ResourceRequirements{
-						Requests: corev1.ResourceList{
-							"storage": resource.MustParse(StorageRequest),
-						},
-					},
-				},
-			},
-			},
+			VolumeClaimTemplates: volumeClaimTemplates(servicesName, storage),
 		},
 	}
+	customiseContainer(&statefulSet.Spec.Template.Spec.Containers[0], spec, mongoResources)
+	applyProbes(&statefulSet.Spec.Template.Spec.Containers[0], spec, mongoProbe())
+	applyPlacement(&statefulSet.Spec.Template.Spec, placementFor(app, statefulSetName, TierData))
+	if ReplicaSetMode(app) {
+		statefulSet.Spec.Replicas = pointer.Int32Ptr(replicaSetMembers(app, statefulSetName))
+		statefulSet.Spec.ServiceName = HeadlessServiceName(statefulSetName)
+		applyReplicaSet(app, statefulSetName, &statefulSet.Spec.Template.Spec)
+	}
+	if _, ok := credentials[statefulSetName]; ok {
+		enforceAuth(&statefulSet.Spec.Template.Spec.Containers[0])
+	}
 
 	return statefulsets.From(statefulSet)
 }
 
+// DeploymentForMem returns the Deployment of the memcached instance of a service. The returned
+// resource is nil when the instance has several replicas, which run as a StatefulSet instead
 func DeploymentForMem(servicesName string, app *examplev1beta1.HotelReservationApp) resources.Reconcileable {
 
-	//imageName := "cp.icr.io/cp/opencontent-audit-webhook@sha256:f4935b3a1687aeb23922fd144f880cc5a4f00404e794a4e30cccd6392cbe29f5"
-	//if len(strings.TrimSpace(webHook.Spec.DockerRegistryPrefix)) > 0 {
-	//	imageName = webHook.Spec.DockerRegistryPrefix + "/opencontent-audit-webhook@sha256:f4935b3a1687aeb23922fd144f880cc5a4f00404e794a4e30cccd6392cbe29f5"
-	//}
-
 	deployName := "memcached-" + servicesName
+	if CacheKind(app, deployName) != KindDeployment {
+		return deployments.From(nil)
+	}
 
 	// Instantialize the data structure
 	deployment := &appsv1.Deployment{
@@ -141,64 +193,30 @@ func DeploymentForMem(servicesName string, app *examplev1beta1.HotelReservationA
 		},
 		Spec: appsv1.DeploymentSpec{
 			// The replica is computed
-			Replicas: pointer.Int32Ptr(1),
+			Replicas: pointer.Int32Ptr(memcachedReplicas(app, deployName)),
 			Selector: &metav1.LabelSelector{
 				MatchLabels: map[string]string{
 					"io.kompose.service": deployName,
 				},
 			},
-			Template: corev1.PodTemplateSpec{
-				ObjectMeta: metav1.ObjectMeta{
-					Labels: map[string]string{
-						"io.kompose.service": deployName,
-					},
-				},
-				Spec: corev1.PodSpec{
-					NodeSelector: map[string]string{
-						"kubernetes.io/hostname": app.Spec.DataNodeName,
-					},
-					Containers: []corev1.Container{{
-						Image:           "memcached",
-						ImagePullPolicy: "IfNotPresent",
-						Name:            "hotelreservation-" + deployName,
-						Ports: []corev1.ContainerPort{{
-							ContainerPort: 11211,
-						}},
-						Env: []corev1.EnvVar{
-							{
-								Name:  "MEMCACHED_CACHE_SIZE",
-								Value: "2048",
-							},
-							{
-								Name:  "MEMCACHED_THREADS",
-								Value: "8",
-							},
-						},
-					}},
-					RestartPolicy: corev1.RestartPolicyAlways,
-				},
-			},
+			Template: memcachedPodTemplate(app, deployName),
 		},
 	}
 
 	return deployments.From(deployment)
 }
 
-func DeploymentForLogic(deployName string, port int32, app *examplev1beta1.HotelReservationApp) resources.Reconcileable {
+func DeploymentForLogic(component Component, app *examplev1beta1.HotelReservationApp, credentials MongoCredentials) resources.Reconcileable {
+
+	deployName := component.Name
+	port := component.Port
 
 	isRunAsRoot := true
 	pIsRunAsRoot := &isRunAsRoot //bool pointer
 
 	var runAsUser int64 = 1000321000
 
-	hostName := app.Spec.LogicNodeName
-	if deployName == "search" {
-		hostName = app.Spec.DataNodeName
-	}
-	//imageName := "cp.icr.io/cp/opencontent-audit-webhook@sha256:f4935b3a1687aeb23922fd144f880cc5a4f00404e794a4e30cccd6392cbe29f5"
-	//if len(strings.TrimSpace(webHook.Spec.DockerRegistryPrefix)) > 0 {
-	//	imageName = webHook.Spec.DockerRegistryPrefix + "/opencontent-audit-webhook@sha256:f4935b3a1687aeb23922fd144f880cc5a4f00404e794a4e30cccd6392cbe29f5"
-	//}
+	spec := componentSpec(app, deployName)
 
 	// Instantialize the data structure
 	deployment := &appsv1.Deployment{
@@ -210,7 +228,7 @@ func DeploymentForLogic(deployName string, port int32, app *examplev1beta1.Hotel
 		},
 		Spec: appsv1.DeploymentSpec{
 			// The replica should be computed
-			Replicas: pointer.Int32Ptr(1),
+			Replicas: replicasFor(spec),
 			Selector: &metav1.LabelSelector{
 				MatchLabels: map[string]string{
 					"io.kompose.service": deployName,
@@ -224,89 +242,72 @@ func DeploymentForLogic(deployName string, port int32, app *examplev1beta1.Hotel
 					Labels: map[string]string{
 						"io.kompose.service": deployName,
 					},
+					Annotations: map[string]string{
+						ConfigHashAnnotation: ConfigHash(ServiceConfig(app, credentials)),
+					},
 				},
 				Spec: corev1.PodSpec{
-					NodeSelector: map[string]string{
-						"kubernetes.io/hostname": hostName,
-					},
+					ImagePullSecrets: app.Spec.ImagePullSecrets,
 					SecurityContext: &corev1.PodSecurityContext{
 						RunAsUser:    &runAsUser,
 						RunAsNonRoot: pIsRunAsRoot,
 					},
-					InitContainers: []corev1.Container{{
-						Image:           "youngpig/configwriter:latest",
-						ImagePullPolicy: "IfNotPresent",
-						Name:            "configwriter",
-
-						SecurityContext: &corev1.SecurityContext{
-							RunAsNonRoot: pIsRunAsRoot,
-						},
-						Env: []corev1.EnvVar{
-							{
-								Name:  "LOGICNODEIP",
-								Value: app.Spec.LogicNodeIp,
-							},
-							{
-								Name:  "DATANODEIP",
-								Value: app.Spec.DataNodeIp,
-							},
-						},
-						VolumeMounts: []corev1.VolumeMount{
-							{
-								MountPath: "/var/configFiles",
-								Name:      "varconfig",
-							},
-						},
-					}},
 					Containers: []corev1.Container{{
-						Image:           "youngpig/hotel_reservation",
-						ImagePullPolicy: "IfNotPresent",
+						Image:           imageFor(app, spec, component.Image, ""),
+						ImagePullPolicy: pullPolicyFor(spec),
 						Name:            "hotelreservation-" + deployName,
 						Command:         []string{deployName},
 						Ports: []corev1.ContainerPort{{
 							HostPort:      port,
 							ContainerPort: port,
 						}},
-						Lifecycle: &corev1.Lifecycle{
-							PostStart: &corev1.LifecycleHandler{
-								Exec: &corev1.ExecAction{
-									Command: []string{"/bin/sh", "-c", "sleep 5"},
-								},
-							},
-						},
 						SecurityContext: &corev1.SecurityContext{
 							RunAsNonRoot: pIsRunAsRoot,
 						},
 						VolumeMounts: []corev1.VolumeMount{
 							{
-								MountPath: "/go/src/github.com/harlow/go-micro-services/config",
-								Name:      "varconfig",
+								MountPath: configMountPath,
+								Name:      "config",
+								ReadOnly:  true,
 							},
 						},
 					}},
 					RestartPolicy: corev1.RestartPolicyAlways,
 					Volumes: []corev1.Volume{
 						{
-							Name: "varconfig",
-							VolumeSource: corev1.VolumeSource{
-								EmptyDir: &corev1.EmptyDirVolumeSource{},
-							},
+							Name:         "config",
+							VolumeSource: configVolumeSource(app),
 						},
 					},
 				},
 			},
 		},
 	}
+	customiseContainer(&deployment.Spec.Template.Spec.Containers[0], spec, logicResources)
+	// The frontend serves HTTP while the other services only serve gRPC, which Kubernetes cannot
+	// probe natively yet, so they are probed for accepting connections
+	if component.Tier == TierFrontend {
+		applyProbes(&deployment.Spec.Template.Spec.Containers[0], spec, httpProbe("/", port))
+	} else {
+		applyProbes(&deployment.Spec.Template.Spec.Containers[0], spec, tcpProbe(port))
+	}
+	if InCluster(app) {
+		dropHostPorts(&deployment.Spec.Template.Spec.Containers[0])
+	}
+	if Autoscaled(app, deployName) {
+		// The replica count is left to the HorizontalPodAutoscaler, and the pods are replaced
+		// progressively so that the service keeps serving while its replicas roll out
+		deployment.Spec.Replicas = nil
+		deployment.Spec.Strategy = appsv1.DeploymentStrategy{Type: appsv1.RollingUpdateDeploymentStrategyType}
+	}
+	applyPlacement(&deployment.Spec.Template.Spec, placementFor(app, deployName, component.Tier))
 
 	return deployments.From(deployment)
 }
 
 func DeploymentForConsul(app *examplev1beta1.HotelReservationApp) resources.Reconcileable {
 
-	//imageName := "cp.icr.io/cp/opencontent-audit-webhook@sha256:f4935b3a1687aeb23922fd144f880cc5a4f00404e794a4e30cccd6392cbe29f5"
-	//if len(strings.TrimSpace(webHook.Spec.DockerRegistryPrefix)) > 0 {
-	//	imageName = webHook.Spec.DockerRegistryPrefix + "/opencontent-audit-webhook@sha256:f4935b3a1687aeb23922fd144f880cc5a4f00404e794a4e30cccd6392cbe29f5"
-	//}
+	spec := componentSpec(app, "consul")
 
 	// Instantialize the data structure
 	deployment := &appsv1.Deployment{
@@ -319,7 +320,7 @@ func DeploymentForConsul(app *examplev1beta1.HotelReservationApp) resources.Reco
 		},
 		Spec: appsv1.DeploymentSpec{
 			// The replica is computed
-			Replicas: pointer.Int32Ptr(1),
+			Replicas: replicasFor(spec),
 			Selector: &metav1.LabelSelector{
 				MatchLabels: map[string]string{
 					"io.kompose.service": "consul",
@@ -332,12 +333,10 @@ func DeploymentForConsul(app *examplev1beta1.HotelReservationApp) resources.Reco
 					},
 				},
 				Spec: corev1.PodSpec{
-					NodeSelector: map[string]string{
-						"kubernetes.io/hostname": app.Spec.LogicNodeName,
-					},
+					ImagePullSecrets: app.Spec.ImagePullSecrets,
 					Containers: []corev1.Container{{
-						Image:           "consul",
-						ImagePullPolicy: "IfNotPresent",
+						Image:           imageFor(app, spec, ConsulImage, ""),
+						ImagePullPolicy: pullPolicyFor(spec),
 						Name:            "consul",
 						Ports: []corev1.ContainerPort{{
 							HostPort:      8300,
@@ -359,16 +358,19 @@ func DeploymentForConsul(app *examplev1beta1.HotelReservationApp) resources.Reco
 			},
 		},
 	}
+	customiseContainer(&deployment.Spec.Template.Spec.Containers[0], spec, consulResources)
+	applyProbes(&deployment.Spec.Template.Spec.Containers[0], spec, httpProbe("/v1/status/leader", 8500))
+	if InCluster(app) {
+		dropHostPorts(&deployment.Spec.Template.Spec.Containers[0])
+	}
+	applyPlacement(&deployment.Spec.Template.Spec, placementFor(app, "consul", TierLogic))
 
 	return deployments.From(deployment)
 }
 
 func DeploymentForJaeger(app *examplev1beta1.HotelReservationApp) resources.Reconcileable {
 
-	//imageName := "cp.icr.io/cp/opencontent-audit-webhook@sha256:f4935b3a1687aeb23922fd144f880cc5a4f00404e794a4e30cccd6392cbe29f5"
-	//if len(strings.TrimSpace(webHook.Spec.DockerRegistryPrefix)) > 0 {
-	//	imageName = webHook.Spec.DockerRegistryPrefix + "/opencontent-audit-webhook@sha256:f4935b3a1687aeb23922fd144f880cc5a4f00404e794a4e30cccd6392cbe29f5"
-	//}
+	spec := componentSpec(app, "jaeger")
 
 	// Instantialize the data structure
 	deployment := &appsv1.Deployment{
@@ -381,7 +383,7 @@ func DeploymentForJaeger(app *examplev1beta1.HotelReservationApp) resources.Reco
 		},
 		Spec: appsv1.DeploymentSpec{
 			// The replica is computed
-			Replicas: pointer.Int32Ptr(1),
+			Replicas: replicasFor(spec),
 			Selector: &metav1.LabelSelector{
 				MatchLabels: map[string]string{
 					"io.kompose.service": "jaeger",
@@ -394,12 +396,10 @@ func DeploymentForJaeger(app *examplev1beta1.HotelReservationApp) resources.Reco
 					},
 				},
 				Spec: corev1.PodSpec{
-					NodeSelector: map[string]string{
-						"kubernetes.io/hostname": app.Spec.LogicNodeName,
-					},
+					ImagePullSecrets: app.Spec.ImagePullSecrets,
 					Containers: []corev1.Container{{
-						Image:           "jaegertracing/all-in-one:latest",
-						ImagePullPolicy: "IfNotPresent",
+						Image:           imageFor(app, spec, JaegerImage, JaegerTag),
+						ImagePullPolicy: pullPolicyFor(spec),
 						Name:            "jaeger",
 						Ports: []corev1.ContainerPort{{
 							ContainerPort: 14269,
@@ -433,6 +433,12 @@ func DeploymentForJaeger(app *examplev1beta1.HotelReservationApp) resources.Reco
 			},
 		},
 	}
+	customiseContainer(&deployment.Spec.Template.Spec.Containers[0], spec, jaegerResources)
+	applyProbes(&deployment.Spec.Template.Spec.Containers[0], spec, httpProbe("/", 14269))
+	if InCluster(app) {
+		dropHostPorts(&deployment.Spec.Template.Spec.Containers[0])
+	}
+	applyPlacement(&deployment.Spec.Template.Spec, placementFor(app, "jaeger", TierLogic))
 
 	return deployments.From(deployment)
 }
@@ -0,0 +1,52 @@
+package operator
+
+import (
+	examplev1beta1 "github.com/Youngpig1998/hotelreservation-operator/api/v1beta1"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("Services", func() {
+	It("exposes the gRPC port of a logic service", func() {
+		service := ServiceForLogic(catalogueComponent("geo"), newApp(nil)).GetResource().(*corev1.Service)
+		Expect(service.Spec.Type).To(Equal(corev1.ServiceTypeClusterIP))
+		Expect(service.Spec.Ports).To(HaveLen(1))
+		Expect(service.Spec.Ports[0].Name).To(Equal("grpc"))
+		Expect(service.Spec.Ports[0].Port).To(Equal(int32(8083)))
+	})
+
+	It("exposes the frontend on its own tier", func() {
+		app := newApp(nil)
+		app.Spec.Exposure = &examplev1beta1.ExposureSpec{Frontend: &examplev1beta1.FrontendExposure{ServiceExposure: examplev1beta1.ServiceExposure{
+			Type:      corev1.ServiceTypeNodePort,
+			NodePorts: map[string]int32{"frontend": 30080},
+		}}}
+
+		service := ServiceForLogic(catalogueComponent("frontend"), app).GetResource().(*corev1.Service)
+		Expect(service.Spec.Type).To(Equal(corev1.ServiceTypeNodePort))
+		Expect(service.Spec.Ports[0].Name).To(Equal("http"))
+		Expect(service.Spec.Ports[0].NodePort).To(Equal(int32(30080)))
+	})
+
+	It("pins the node port of the first port only", func() {
+		app := newApp(nil)
+		app.Spec.Exposure = &examplev1beta1.ExposureSpec{Logic: &examplev1beta1.ServiceExposure{
+			Type:      corev1.ServiceTypeNodePort,
+			NodePorts: map[string]int32{"consul": 30500},
+		}}
+
+		service := ServiceForConsul(app).GetResource().(*corev1.Service)
+		Expect(service.Spec.Ports).To(HaveLen(4))
+		Expect(service.Spec.Ports[0].Port).To(Equal(int32(8500)))
+		Expect(service.Spec.Ports[0].NodePort).To(Equal(int32(30500)))
+		for _, port := range service.Spec.Ports[1:] {
+			Expect(port.NodePort).To(BeZero())
+		}
+	})
+
+	It("exposes the jaeger agent over UDP", func() {
+		service := ServiceForJaeger(newApp(nil)).GetResource().(*corev1.Service)
+		Expect(service.Spec.Ports).To(ContainElement(servicePort("agent-compact", corev1.ProtocolUDP, 6831, 6831)))
+	})
+})
@@ -0,0 +1,135 @@
+package operator
+
+import (
+	"path"
+
+	examplev1beta1 "github.com/Youngpig1998/hotelreservation-operator/api/v1beta1"
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/resources"
+	"github.com/Youngpig1998/hotelreservation-operator/iaw-shared-helpers/pkg/resources/jobs"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+// restoreScript waits for the instance given in $HOST to accept writes, then restores the archive
+// given in $ARCHIVE into it. The collections of the backup replace the existing ones, while the
+// users are left out so that the instance keeps its own credentials
+const restoreScript = `set -e
+shell=mongosh; command -v mongosh >/dev/null || shell=mongo
+set -- --quiet --host "$HOST"
+if [ -n "$ROOT_PASSWORD" ]; then set -- "$@" --username "$ROOT_USERNAME" --password "$ROOT_PASSWORD" --authenticationDatabase admin; fi
+until $shell "$@" --eval 'quit(db.adminCommand({isMaster: 1}).ismaster ? 0 : 1)'; do sleep 5; done
+mongorestore "$@" --gzip --archive="$ARCHIVE" --drop --nsExclude 'admin.*' --nsExclude 'config.*'`
+
+// s3DownloadScript downloads the archive of the backup from $BUCKET into $ARCHIVE
+const s3DownloadScript = `set -e
+aws ${ENDPOINT:+--endpoint-url "$ENDPOINT"} s3 cp "s3://$BUCKET/$KEY" "$ARCHIVE"`
+
+// RestoreJobName returns the name of the Job restoring a mongodb instance from a backup
+func RestoreJobName(name string) string {
+	return name + "-restore"
+}
+
+// backupArchiveName returns the name of the archive of a backup
+func backupArchiveName(backup string) string {
+	return backup + ".archive.gz"
+}
+
+// RestoreJob returns the Job restoring a mongodb instance from the backup named in the spec. The
+// archive is read from a PersistentVolumeClaim source directly, while an init container
+// downloads it from an S3 source into a scratch volume first. The returned resource is nil when
+// the app is not restored from a backup
+func RestoreJob(app *examplev1beta1.HotelReservationApp, name string) resources.Reconcileable {
+	restore := app.Spec.Restore
+	if restore == nil {
+		return jobs.From(nil)
+	}
+	spec := componentSpec(app, name)
+
+	env := []corev1.EnvVar{
+		{Name: "HOST", Value: shellHost(app, name)},
+	}
+	if AuthEnabled(app) {
+		env = append(env,
+			secretKeyEnv("ROOT_USERNAME", CredentialsSecretName(name), RootUsernameKey, true),
+			secretKeyEnv("ROOT_PASSWORD", CredentialsSecretName(name), RootPasswordKey, true),
+		)
+	}
+	container := corev1.Container{
+		Name:            "restore",
+		Image:           imageFor(app, spec, MongoImage, ""),
+		ImagePullPolicy: pullPolicyFor(spec),
+		Command:         []string{"sh", "-c", restoreScript},
+		Env:             env,
+		VolumeMounts:    []corev1.VolumeMount{{Name: "backup", MountPath: backupMountPath, ReadOnly: true}},
+	}
+
+	podSpec := corev1.PodSpec{
+		ImagePullSecrets: app.Spec.ImagePullSecrets,
+		RestartPolicy:    corev1.RestartPolicyOnFailure,
+	}
+	if source := restore.Source.PersistentVolumeClaim; source != nil {
+		archive := path.Join(backupMountPath, source.Path, name, backupArchiveName(restore.Backup))
+		container.Env = append(container.Env, corev1.EnvVar{Name: "ARCHIVE", Value: archive})
+		podSpec.Volumes = []corev1.Volume{{
+			Name: "backup",
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: source.ClaimName, ReadOnly: true},
+			},
+		}}
+	} else if source := restore.Source.S3; source != nil {
+		archive := path.Join(backupMountPath, backupArchive)
+		container.Env = append(container.Env, corev1.EnvVar{Name: "ARCHIVE", Value: archive})
+		region := source.Region
+		if region == "" {
+			region = defaultBackupRegion
+		}
+		downloaderSpec := examplev1beta1.ComponentSpec{Image: source.Image, ImagePullPolicy: spec.ImagePullPolicy}
+		podSpec.InitContainers = []corev1.Container{{
+			Name:            "download",
+			Image:           imageFor(app, downloaderSpec, BackupUploaderImage, ""),
+			ImagePullPolicy: pullPolicyFor(downloaderSpec),
+			Command:         []string{"sh", "-c", s3DownloadScript},
+			Env: []corev1.EnvVar{
+				{Name: "ENDPOINT", Value: source.Endpoint},
+				{Name: "BUCKET", Value: source.Bucket},
+				{Name: "KEY", Value: path.Join(source.Prefix, name, backupArchiveName(restore.Backup))},
+				{Name: "ARCHIVE", Value: archive},
+				{Name: "AWS_DEFAULT_REGION", Value: region},
+				secretKeyEnv(awsAccessKeyIDKey, source.CredentialsSecretName, awsAccessKeyIDKey, false),
+				secretKeyEnv(awsSecretAccessKeyKey, source.CredentialsSecretName, awsSecretAccessKeyKey, false),
+			},
+			VolumeMounts: []corev1.VolumeMount{{Name: "backup", MountPath: backupMountPath}},
+		}}
+		podSpec.Volumes = []corev1.Volume{{
+			Name: "backup",
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		}}
+	}
+	podSpec.Containers = []corev1.Container{container}
+	applyPlacement(&podSpec, placementFor(app, name, TierData))
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: RestoreJobName(name),
+			Labels: map[string]string{
+				"io.kompose.service": RestoreJobName(name),
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: pointer.Int32Ptr(6),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"io.kompose.service": RestoreJobName(name),
+					},
+				},
+				Spec: podSpec,
+			},
+		},
+	}
+	return jobs.From(job)
+}
@@ -0,0 +1,43 @@
+package operator
+
+import (
+	examplev1beta1 "github.com/Youngpig1998/hotelreservation-operator/api/v1beta1"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+)
+
+// restoreApp returns a HotelReservationApp restored from the named backup of the given source
+func restoreApp(backup string, source examplev1beta1.BackupTarget) *examplev1beta1.HotelReservationApp {
+	app := newApp(nil)
+	app.Spec.Restore = &examplev1beta1.RestoreSpec{Backup: backup, Source: source}
+	return app
+}
+
+var _ = Describe("Restore", func() {
+	It("creates no Job unless the app is restored", func() {
+		Expect(RestoreJob(newApp(nil), "mongodb-geo").ResourceIsNil()).To(BeTrue())
+	})
+
+	It("restores the archive of the backup from the PersistentVolumeClaim source", func() {
+		app := restoreApp("20220101T000000Z", examplev1beta1.BackupTarget{PersistentVolumeClaim: &examplev1beta1.PVCBackupTarget{ClaimName: "backups", Path: "hotel"}})
+		job := RestoreJob(app, "mongodb-geo").GetResource().(*batchv1.Job)
+		Expect(job.Name).To(Equal("mongodb-geo-restore"))
+
+		podSpec := job.Spec.Template.Spec
+		Expect(podSpec.InitContainers).To(BeEmpty())
+		Expect(envValue(podSpec.Containers[0], "ARCHIVE")).To(Equal("/backup/hotel/mongodb-geo/20220101T000000Z.archive.gz"))
+		Expect(podSpec.Volumes[0].PersistentVolumeClaim.ClaimName).To(Equal("backups"))
+		Expect(podSpec.Volumes[0].PersistentVolumeClaim.ReadOnly).To(BeTrue())
+	})
+
+	It("downloads the archive of the backup from the S3 source first", func() {
+		app := restoreApp("20220101T000000Z", examplev1beta1.BackupTarget{S3: &examplev1beta1.S3BackupTarget{Bucket: "backups", Prefix: "hotel", CredentialsSecretName: "aws"}})
+		podSpec := RestoreJob(app, "mongodb-geo").GetResource().(*batchv1.Job).Spec.Template.Spec
+		Expect(podSpec.InitContainers).To(HaveLen(1))
+		download := podSpec.InitContainers[0]
+		Expect(envValue(download, "KEY")).To(Equal("hotel/mongodb-geo/20220101T000000Z.archive.gz"))
+		Expect(envValue(download, "ARCHIVE")).To(Equal(envValue(podSpec.Containers[0], "ARCHIVE")))
+		Expect(podSpec.Volumes[0].EmptyDir).NotTo(BeNil())
+	})
+})
@@ -0,0 +1,88 @@
+package operator
+
+import (
+	examplev1beta1 "github.com/Youngpig1998/hotelreservation-operator/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// storageFor resolves the storage of a mongodb instance from the storage of the spec and the
+// overrides of the instance's component, then fills in the defaults
+func storageFor(app *examplev1beta1.HotelReservationApp, name string) examplev1beta1.StorageSpec {
+	storage := examplev1beta1.StorageSpec{}
+	if app.Spec.Storage != nil {
+		storage = *app.Spec.Storage.DeepCopy()
+	}
+
+	if override := componentSpec(app, name).Storage; override != nil {
+		override = override.DeepCopy()
+		if override.Size != nil {
+			storage.Size = override.Size
+		}
+		if override.StorageClassName != nil {
+			storage.StorageClassName = override.StorageClassName
+		}
+		if len(override.AccessModes) != 0 {
+			storage.AccessModes = override.AccessModes
+		}
+		if override.EmptyDir {
+			storage.EmptyDir = true
+		}
+	}
+
+	if storage.Size == nil {
+		size := resource.MustParse(StorageRequest)
+		storage.Size = &size
+	}
+	if len(storage.AccessModes) == 0 {
+		storage.AccessModes = []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}
+	}
+	return storage
+}
+
+// VolumeSize returns the size requested for the volume of each replica of a mongodb instance,
+// nil in emptyDir mode where there is no PersistentVolumeClaim to size
+func VolumeSize(app *examplev1beta1.HotelReservationApp, name string) *resource.Quantity {
+	storage := storageFor(app, name)
+	if storage.EmptyDir {
+		return nil
+	}
+	return storage.Size
+}
+
+// volumeClaimTemplates returns the claim templates of the volume named volumeName of a mongodb
+// instance, none in emptyDir mode
+func volumeClaimTemplates(volumeName string, storage examplev1beta1.StorageSpec) []corev1.PersistentVolumeClaim {
+	if storage.EmptyDir {
+		return nil
+	}
+	return []corev1.PersistentVolumeClaim{{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: volumeName,
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      storage.AccessModes,
+			StorageClassName: storage.StorageClassName,
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: *storage.Size,
+				},
+			},
+		},
+	}}
+}
+
+// volumes returns the pod volumes of a mongodb instance, an emptyDir volume in emptyDir mode
+// and none otherwise since the volume comes from the claim templates
+func volumes(volumeName string, storage examplev1beta1.StorageSpec) []corev1.Volume {
+	if !storage.EmptyDir {
+		return nil
+	}
+	return []corev1.Volume{{
+		Name: volumeName,
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{SizeLimit: storage.Size},
+		},
+	}}
+}
@@ -0,0 +1,68 @@
+package operator
+
+import (
+	examplev1beta1 "github.com/Youngpig1998/hotelreservation-operator/api/v1beta1"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/pointer"
+)
+
+// quantity returns a pointer to the parsed quantity
+func quantity(value string) *resource.Quantity {
+	parsed := resource.MustParse(value)
+	return &parsed
+}
+
+var _ = Describe("Storage", func() {
+	DescribeTable("storageFor",
+		func(storage *examplev1beta1.StorageSpec, override *examplev1beta1.StorageSpec, expected examplev1beta1.StorageSpec) {
+			app := newApp(map[string]examplev1beta1.ComponentSpec{"mongodb-geo": {Storage: override}})
+			app.Spec.Storage = storage
+			Expect(storageFor(app, "mongodb-geo")).To(Equal(expected))
+		},
+		Entry("fills in the defaults", nil, nil, examplev1beta1.StorageSpec{
+			Size:        quantity(StorageRequest),
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+		}),
+		Entry("uses the storage of the spec",
+			&examplev1beta1.StorageSpec{Size: quantity("5Gi"), StorageClassName: pointer.StringPtr("fast")}, nil,
+			examplev1beta1.StorageSpec{
+				Size:             quantity("5Gi"),
+				StorageClassName: pointer.StringPtr("fast"),
+				AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			}),
+		Entry("applies the overrides of the instance over the storage of the spec",
+			&examplev1beta1.StorageSpec{Size: quantity("5Gi"), StorageClassName: pointer.StringPtr("fast")},
+			&examplev1beta1.StorageSpec{Size: quantity("10Gi"), AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany}},
+			examplev1beta1.StorageSpec{
+				Size:             quantity("10Gi"),
+				StorageClassName: pointer.StringPtr("fast"),
+				AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany},
+			}),
+		Entry("switches a single instance to emptyDir", nil, &examplev1beta1.StorageSpec{EmptyDir: true},
+			examplev1beta1.StorageSpec{
+				Size:        quantity(StorageRequest),
+				AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+				EmptyDir:    true,
+			}),
+	)
+
+	It("claims a volume only when the data is persisted", func() {
+		app := newApp(map[string]examplev1beta1.ComponentSpec{"mongodb-geo": {Storage: &examplev1beta1.StorageSpec{EmptyDir: true}}})
+		Expect(VolumeSize(app, "mongodb-geo")).To(BeNil())
+		Expect(VolumeSize(app, "mongodb-user")).To(Equal(quantity(StorageRequest)))
+
+		emptyDir := storageFor(app, "mongodb-geo")
+		Expect(volumeClaimTemplates("data", emptyDir)).To(BeEmpty())
+		Expect(volumes("data", emptyDir)).To(HaveLen(1))
+		Expect(volumes("data", emptyDir)[0].EmptyDir.SizeLimit).To(Equal(quantity(StorageRequest)))
+
+		persisted := storageFor(app, "mongodb-user")
+		Expect(volumes("data", persisted)).To(BeEmpty())
+		Expect(volumeClaimTemplates("data", persisted)).To(HaveLen(1))
+		Expect(volumeClaimTemplates("data", persisted)[0].Spec.Resources.Requests).To(HaveKeyWithValue(corev1.ResourceStorage, resource.MustParse(StorageRequest)))
+	})
+})